@@ -0,0 +1,113 @@
+package medtronic
+
+import (
+	"fmt"
+)
+
+// Field describes a range of bytes in a history record.
+type Field struct {
+	Offset int
+	Length int
+	Name   string
+}
+
+type recordLayout func(family Family) []Field
+
+// Most records consist of a type code, a value, a 5-byte timestamp,
+// and an optional body.
+func baseLayout(body ...Field) recordLayout {
+	return func(Family) []Field {
+		return append([]Field{{1, 1, "value"}, {2, 5, "time"}}, body...)
+	}
+}
+
+func scheduleFields(name string, offset int, n int, size int) []Field {
+	var fields []Field
+	for i := 0; i < n; i++ {
+		fields = append(fields, Field{offset + i*size, size, fmt.Sprintf("%s[%d]", name, i)})
+	}
+	return fields
+}
+
+var recordLayouts = map[HistoryRecordType]recordLayout{
+	Bolus: func(family Family) []Field {
+		if family <= 22 {
+			return []Field{{1, 1, "programmed"}, {2, 1, "amount"}, {3, 1, "duration"}, {4, 5, "time"}}
+		}
+		return []Field{{1, 2, "programmed"}, {3, 2, "amount"}, {5, 2, "unabsorbed"}, {7, 1, "duration"}, {8, 5, "time"}}
+	},
+	Prime: func(Family) []Field {
+		return []Field{{2, 1, "fixed"}, {4, 1, "manual"}, {5, 5, "time"}}
+	},
+	Alarm: func(Family) []Field {
+		return []Field{{1, 1, "alarm"}, {4, 5, "time"}}
+	},
+	SensorAlarm: func(Family) []Field {
+		return []Field{{1, 1, "alarm"}, {3, 5, "time"}}
+	},
+	DailyTotal: func(Family) []Field {
+		return []Field{{3, 2, "total"}, {5, 2, "date"}}
+	},
+	DailyTotal515: dateLayout,
+	DailyTotal522: dateLayout,
+	DailyTotal523: dateLayout,
+	TempBasalRate: baseLayout(Field{7, 1, "type"}),
+	Unknown2E:     baseLayout(Field{7, 50, "before"}, Field{57, 50, "after"}),
+	SensorSetup: func(family Family) []Field {
+		n := 15
+		if family >= 51 {
+			n = 17
+		}
+		return baseLayout(Field{7, n, "before"}, Field{7 + n, n, "after"})(family)
+	},
+	ChangeSensorAlarm: baseLayout(Field{7, 1, "duration"}),
+	Sensor54: baseLayout(append(
+		scheduleFields("before", 10, 8, 3),
+		scheduleFields("after", 38, 8, 3)...)...),
+	Sensor55: baseLayout(append(
+		scheduleFields("before", 7, 8, 3),
+		scheduleFields("after", 31, 8, 3)...)...),
+	ChangeSensorAlert:   baseLayout(Field{10, 1, "rise"}, Field{11, 1, "fall"}),
+	BasalProfileStart:   baseLayout(Field{7, 3, "rate"}),
+	BasalProfileBefore:  baseLayout(scheduleFields("rate", 7, 48, 3)...),
+	BasalProfileAfter:   baseLayout(scheduleFields("rate", 7, 48, 3)...),
+	ConnectOtherDevices: baseLayout(),
+	ChangeOtherDevice:   baseLayout(scheduleFields("device", 7, 6, 5)...),
+	ChangeMarriage:      baseLayout(Field{7, 5, "device"}),
+	DeleteOtherDevice:   baseLayout(Field{7, 5, "device"}),
+}
+
+func dateLayout(Family) []Field {
+	return []Field{{1, 2, "date"}}
+}
+
+// AnnotateHistoryRecord decodes a history record and describes the fields in its data.
+// The type code is the first field, and any bytes not covered by an identified field
+// are described as "unknown".
+func AnnotateHistoryRecord(data []byte, family Family) (HistoryRecord, []Field, error) {
+	r, err := DecodeHistoryRecord(data, family)
+	if err != nil {
+		return r, nil, err
+	}
+	l, found := recordLayouts[r.Type()]
+	if !found {
+		l = baseLayout()
+	}
+	known := l(family)
+	fields := []Field{{0, 1, "type"}}
+	next := 1
+	for _, f := range known {
+		if f.Offset+f.Length > len(r.Data) {
+			break
+		}
+		if f.Offset > next {
+			fields = append(fields, Field{next, f.Offset - next, "unknown"})
+		}
+		fields = append(fields, f)
+		next = f.Offset + f.Length
+	}
+	if next < len(r.Data) {
+		fields = append(fields, Field{next, len(r.Data) - next, "unknown"})
+	}
+	return r, fields, nil
+}
@@ -0,0 +1,42 @@
+package medtronic
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAnnotateHistoryRecord(t *testing.T) {
+	cases := []struct {
+		data   []byte
+		family Family
+		fields []Field
+	}{
+		{
+			parseBytes("81 01 6D C3 15 0A 10 00 A2 14 8C C8"),
+			23,
+			[]Field{{0, 1, "type"}, {1, 1, "value"}, {2, 5, "time"}, {7, 5, "device"}},
+		},
+		{
+			parseBytes("33 00 2D 0B 0A 0A 10 00"),
+			23,
+			[]Field{{0, 1, "type"}, {1, 1, "value"}, {2, 5, "time"}, {7, 1, "type"}},
+		},
+		{
+			parseBytes("07 00 00 00 68 00 A1"),
+			15,
+			[]Field{{0, 1, "type"}, {1, 2, "unknown"}, {3, 2, "total"}, {5, 2, "date"}},
+		},
+	}
+	for _, c := range cases {
+		t.Run(HistoryRecordType(c.data[0]).String(), func(t *testing.T) {
+			_, fields, err := AnnotateHistoryRecord(c.data, c.family)
+			if err != nil {
+				t.Errorf("AnnotateHistoryRecord(% X) returned %v", c.data, err)
+				return
+			}
+			if !reflect.DeepEqual(fields, c.fields) {
+				t.Errorf("AnnotateHistoryRecord(% X) == %v, want %v", c.data, fields, c.fields)
+			}
+		})
+	}
+}
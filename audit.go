@@ -0,0 +1,184 @@
+package medtronic
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DeclaredSettings represents the settings a pump is expected to have.
+// It is decoded from JSON or YAML in the same form as a Backup,
+// and settings that are omitted are not checked.
+type DeclaredSettings struct {
+	Backup
+	declared map[string]bool
+}
+
+// auditedSettings lists the settings compared by Audit,
+// with the names used in ProfileDiff, the JSON fields they are decoded from
+// (within Settings if prefixed by "Settings."), and a function to copy them.
+var auditedSettings = []struct {
+	name  string
+	field string
+	copy  func(dst *Backup, src *Backup)
+}{
+	{"carbunits", "CarbUnits", func(d, s *Backup) { d.CarbUnits = s.CarbUnits }},
+	{"glucoseunits", "GlucoseUnits", func(d, s *Backup) { d.GlucoseUnits = s.GlucoseUnits }},
+	{"insulinaction", "Settings.InsulinAction", func(d, s *Backup) { d.Settings.InsulinAction = s.Settings.InsulinAction }},
+	{"concentration", "Settings.InsulinConcentration", func(d, s *Backup) { d.Settings.InsulinConcentration = s.Settings.InsulinConcentration }},
+	{"autooff", "Settings.AutoOff", func(d, s *Backup) { d.Settings.AutoOff = s.Settings.AutoOff }},
+	{"tempbasaltype", "Settings.TempBasalType", func(d, s *Backup) { d.Settings.TempBasalType = s.Settings.TempBasalType }},
+	{"maxbasal", "Settings.MaxBasal", func(d, s *Backup) { d.Settings.MaxBasal = s.Settings.MaxBasal }},
+	{"maxbolus", "Settings.MaxBolus", func(d, s *Backup) { d.Settings.MaxBolus = s.Settings.MaxBolus }},
+	{"basal", "BasalRates", func(d, s *Backup) { d.BasalRates = s.BasalRates }},
+	{"patterna", "BasalPatternA", func(d, s *Backup) { d.BasalPatternA = s.BasalPatternA }},
+	{"patternb", "BasalPatternB", func(d, s *Backup) { d.BasalPatternB = s.BasalPatternB }},
+	{"pattern", "Settings.SelectedPattern", func(d, s *Backup) { d.Settings.SelectedPattern = s.Settings.SelectedPattern }},
+	{"carbratio", "CarbRatios", func(d, s *Backup) { d.CarbRatios = s.CarbRatios }},
+	{"sens", "Sensitivities", func(d, s *Backup) { d.Sensitivities = s.Sensitivities }},
+	{"target", "Targets", func(d, s *Backup) { d.Targets = s.Targets }},
+}
+
+// jsonFields returns the set of field names (in lower case) in a JSON object.
+func jsonFields(data []byte) (map[string]json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	err := json.Unmarshal(data, &fields)
+	if err != nil {
+		return nil, err
+	}
+	lower := make(map[string]json.RawMessage, len(fields))
+	for k, v := range fields {
+		lower[strings.ToLower(k)] = v
+	}
+	return lower, nil
+}
+
+// DecodeDeclaredSettings decodes a declared configuration from JSON.
+func DecodeDeclaredSettings(data []byte) (DeclaredSettings, error) {
+	var d DeclaredSettings
+	err := json.Unmarshal(data, &d.Backup)
+	if err != nil {
+		return d, err
+	}
+	top, err := jsonFields(data)
+	if err != nil {
+		return d, err
+	}
+	settings := make(map[string]json.RawMessage)
+	if s, found := top["settings"]; found {
+		settings, err = jsonFields(s)
+		if err != nil {
+			return d, err
+		}
+	}
+	d.declared = make(map[string]bool)
+	for _, s := range auditedSettings {
+		field := strings.ToLower(s.field)
+		if strings.HasPrefix(field, "settings.") {
+			_, d.declared[s.name] = settings[strings.TrimPrefix(field, "settings.")]
+		} else {
+			_, d.declared[s.name] = top[field]
+		}
+	}
+	return d, nil
+}
+
+// DecodeDeclaredSettingsYAML decodes a declared configuration from YAML,
+// with the same field names and value formats as the JSON form.
+func DecodeDeclaredSettingsYAML(data []byte) (DeclaredSettings, error) {
+	var v interface{}
+	err := yaml.Unmarshal(data, &v)
+	if err != nil {
+		return DeclaredSettings{}, err
+	}
+	data, err = json.Marshal(v)
+	if err != nil {
+		return DeclaredSettings{}, err
+	}
+	return DecodeDeclaredSettings(data)
+}
+
+// Audit returns the declared settings that differ from the pump's current settings.
+func (d DeclaredSettings) Audit(current Backup) []ProfileDiff {
+	b := d.Backup
+	for _, s := range auditedSettings {
+		if !d.declared[s.name] {
+			s.copy(&b, &current)
+		}
+	}
+	return b.Diff(current)
+}
+
+// SettingChange describes a change to a pump setting recorded in its history,
+// using the names in ProfileDiff.
+// Before is empty if the history record does not include the previous value,
+// and both Before and After are empty if it includes neither.
+type SettingChange struct {
+	Name   string
+	Time   time.Time
+	Before []string `json:",omitempty"`
+	After  []string `json:",omitempty"`
+}
+
+// SettingChanges returns the most recent change to each setting in the given history records,
+// which must be in reverse chronological order (most recent first).
+// Bolus wizard setup records are compared to report only the schedules that changed;
+// on pumps that record ChangeBolusWizardSetup without the values, the change is reported as "boluswizard".
+func SettingChanges(records History) map[string]SettingChange {
+	changes := make(map[string]SettingChange)
+	add := func(r HistoryRecord, name string, before []string, after []string) {
+		if _, found := changes[name]; found {
+			return
+		}
+		changes[name] = SettingChange{Name: name, Time: r.Time, Before: before, After: after}
+	}
+	value := func(v interface{}) []string {
+		if d, ok := v.(Duration); ok {
+			v = time.Duration(d)
+		}
+		return []string{fmt.Sprint(v)}
+	}
+	for _, r := range records {
+		switch r.Type() {
+		case ChangeBasalPattern:
+			add(r, "pattern", nil, value(r.Info))
+		case MaxBasal:
+			add(r, "maxbasal", nil, value(r.Info))
+		case MaxBolus:
+			add(r, "maxbolus", nil, value(r.Info))
+		case ChangeTempBasalType:
+			add(r, "tempbasaltype", nil, value(r.Info))
+		case SetAutoOff:
+			add(r, "autooff", nil, value(r.Info))
+		case ChangeCarbUnits:
+			if n, ok := r.Info.(int); ok {
+				add(r, "carbunits", nil, value(CarbUnitsType(n)))
+			}
+		case ChangeBolusWizardSetup:
+			add(r, "boluswizard", nil, nil)
+		case BolusWizardSetup, Unknown2E:
+			setup, ok := r.Info.(BolusWizardSetupRecord)
+			if !ok {
+				break
+			}
+			before, after := setup.Before, setup.After
+			for _, s := range []struct {
+				name          string
+				before, after []string
+			}{
+				{"carbratio", before.Ratios.entries(), after.Ratios.entries()},
+				{"sens", before.Sensitivities.entries(), after.Sensitivities.entries()},
+				{"target", before.Targets.entries(), after.Targets.entries()},
+				{"insulinaction", value(before.InsulinAction), value(after.InsulinAction)},
+			} {
+				if !sameEntries(s.before, s.after) {
+					add(r, s.name, s.before, s.after)
+				}
+			}
+		}
+	}
+	return changes
+}
@@ -0,0 +1,113 @@
+package medtronic
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDeclaredSettingsAudit(t *testing.T) {
+	declared := `{
+  "GlucoseUnits": "mg/dL",
+  "Settings": {"MaxBolus": 5, "InsulinAction": "4h0m0s", "SelectedPattern": 0},
+  "BasalRates": [{"Start": "00:00", "Rate": 0.8}, {"Start": "06:00", "Rate": 1}]
+}`
+	d, err := DecodeDeclaredSettings([]byte(declared))
+	if err != nil {
+		t.Fatal(err)
+	}
+	declaredYAML := `
+GlucoseUnits: mg/dL
+Settings:
+  MaxBolus: 5
+  InsulinAction: 4h0m0s
+  SelectedPattern: 0
+BasalRates:
+  - {Start: "00:00", Rate: 0.8}
+  - {Start: "06:00", Rate: 1}
+`
+	y, err := DecodeDeclaredSettingsYAML([]byte(declaredYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(y, d) {
+		t.Errorf("DecodeDeclaredSettingsYAML() == %+v, want %+v", y, d)
+	}
+	current := Backup{
+		Settings: SettingsInfo{
+			InsulinAction: 4 * time.Hour,
+			MaxBolus:      10000,
+			MaxBasal:      2000,
+			AutoOff:       12 * time.Hour,
+		},
+		CarbUnits:    Grams,
+		GlucoseUnits: MgPerDeciLiter,
+		PumpProfile: PumpProfile{
+			BasalRates: BasalRateSchedule{{0, 800}},
+			CarbRatios: CarbRatioSchedule{{0, 150, Grams}},
+		},
+	}
+	diffs := d.Audit(current)
+	want := []ProfileDiff{
+		{Name: "maxbolus", Current: []string{"10"}, New: []string{"5"}},
+		{Name: "basal", Current: []string{"00:00 0.8"}, New: []string{"00:00 0.8", "06:00 1"}},
+	}
+	if !reflect.DeepEqual(diffs, want) {
+		t.Errorf("Audit() == %+v, want %+v", diffs, want)
+	}
+	current.Settings.MaxBolus = 5000
+	current.BasalRates = BasalRateSchedule{{0, 800}, {parseTD("06:00"), 1000}}
+	diffs = d.Audit(current)
+	if len(diffs) != 0 {
+		t.Errorf("Audit() == %+v, want no differences", diffs)
+	}
+}
+
+func TestSettingChanges(t *testing.T) {
+	setup := BolusWizardSetupRecord{
+		Before: BolusWizardConfig{
+			Ratios:        CarbRatioSchedule{{0, 150, Grams}},
+			Sensitivities: InsulinSensitivitySchedule{{0, 50, MgPerDeciLiter}},
+			InsulinAction: Duration(3 * time.Hour),
+		},
+		After: BolusWizardConfig{
+			Ratios:        CarbRatioSchedule{{0, 120, Grams}},
+			Sensitivities: InsulinSensitivitySchedule{{0, 50, MgPerDeciLiter}},
+			InsulinAction: Duration(4 * time.Hour),
+		},
+	}
+	records := History{
+		testRecord(MaxBolus, "12:00", Insulin(5000)),
+		testRecord(BolusWizardSetup, "11:00", setup),
+		testRecord(ChangeBasalPattern, "10:00", 1),
+		testRecord(MaxBolus, "09:00", Insulin(10000)),
+	}
+	changes := SettingChanges(records)
+	want := map[string]SettingChange{
+		"maxbolus": {
+			Name:  "maxbolus",
+			Time:  parseTime("2018-06-20T12:00"),
+			After: []string{"5"},
+		},
+		"carbratio": {
+			Name:   "carbratio",
+			Time:   parseTime("2018-06-20T11:00"),
+			Before: []string{"00:00 150 Grams"},
+			After:  []string{"00:00 120 Grams"},
+		},
+		"insulinaction": {
+			Name:   "insulinaction",
+			Time:   parseTime("2018-06-20T11:00"),
+			Before: []string{"3h0m0s"},
+			After:  []string{"4h0m0s"},
+		},
+		"pattern": {
+			Name:  "pattern",
+			Time:  parseTime("2018-06-20T10:00"),
+			After: []string{"1"},
+		},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("SettingChanges() == %+v, want %+v", changes, want)
+	}
+}
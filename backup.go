@@ -0,0 +1,128 @@
+package medtronic
+
+import (
+	"fmt"
+	"time"
+)
+
+// Backup holds all the pump settings that can be read,
+// so they can be saved (for example, as JSON) and restored
+// to the same pump or to a replacement.
+type Backup struct {
+	Time            time.Time
+	PumpID          string
+	Model           string
+	FirmwareVersion string
+	Settings        SettingsInfo
+	CarbUnits       CarbUnitsType
+	GlucoseUnits    GlucoseUnitsType
+	BasalPatternA   BasalRateSchedule
+	BasalPatternB   BasalRateSchedule
+	PumpProfile
+}
+
+// Backup reads all the pump's settings.
+func (pump *Pump) Backup() Backup {
+	b := Backup{Time: time.Now()}
+	b.Model = pump.Model()
+	b.PumpID = pump.PumpID()
+	b.FirmwareVersion = pump.FirmwareVersion()
+	b.Settings = pump.Settings()
+	b.CarbUnits = pump.CarbUnits()
+	b.GlucoseUnits = pump.GlucoseUnits()
+	b.BasalPatternA = pump.BasalPatternA()
+	b.BasalPatternB = pump.BasalPatternB()
+	if pump.Error() != nil {
+		return Backup{}
+	}
+	b.PumpProfile = pump.Profile()
+	if pump.Error() != nil {
+		return Backup{}
+	}
+	return b
+}
+
+// Diff returns the settings in b that differ from those in current,
+// including read-only settings that Restore cannot change.
+// Empty schedules in b are not compared.
+func (b Backup) Diff(current Backup) []ProfileDiff {
+	var diffs []ProfileDiff
+	add := func(name string, cur []string, v []string, readOnly bool) {
+		if len(v) == 0 || sameEntries(v, cur) {
+			return
+		}
+		diffs = append(diffs, ProfileDiff{Name: name, Current: cur, New: v, ReadOnly: readOnly})
+	}
+	one := func(v interface{}) []string {
+		return []string{fmt.Sprint(v)}
+	}
+	s, cur := b.Settings, current.Settings
+	add("carbunits", one(current.CarbUnits), one(b.CarbUnits), true)
+	add("glucoseunits", one(current.GlucoseUnits), one(b.GlucoseUnits), true)
+	add("insulinaction", one(cur.InsulinAction), one(s.InsulinAction), true)
+	add("concentration", one(cur.InsulinConcentration), one(s.InsulinConcentration), true)
+	add("autooff", one(cur.AutoOff), one(s.AutoOff), true)
+	add("tempbasaltype", one(cur.TempBasalType), one(s.TempBasalType), true)
+	add("maxbasal", one(cur.MaxBasal), one(s.MaxBasal), false)
+	add("maxbolus", one(cur.MaxBolus), one(s.MaxBolus), false)
+	add("basal", current.BasalRates.entries(), b.BasalRates.entries(), false)
+	add("patterna", current.BasalPatternA.entries(), b.BasalPatternA.entries(), false)
+	add("patternb", current.BasalPatternB.entries(), b.BasalPatternB.entries(), false)
+	add("pattern", one(cur.SelectedPattern), one(s.SelectedPattern), false)
+	add("carbratio", current.CarbRatios.entries(), b.CarbRatios.entries(), true)
+	add("sens", current.Sensitivities.entries(), b.Sensitivities.entries(), true)
+	add("target", current.Targets.entries(), b.Targets.entries(), true)
+	return diffs
+}
+
+// Restore writes the settings in b that differ from the pump's current settings,
+// and returns the differences, including read-only settings that are not written.
+// If dryRun is true, the differences are returned without writing anything.
+// The pump's carb and glucose units must already match those in b.
+// Maximum basal and bolus settings that are being raised are written first,
+// and those being lowered are written last, so the schedules are always within the limits.
+func (pump *Pump) Restore(b Backup, dryRun bool) []ProfileDiff {
+	current := pump.Backup()
+	if pump.Error() != nil {
+		return nil
+	}
+	diffs := b.Diff(current)
+	if dryRun {
+		return diffs
+	}
+	if b.CarbUnits != current.CarbUnits || b.GlucoseUnits != current.GlucoseUnits {
+		pump.SetError(fmt.Errorf("pump units (%v, %v) must be changed to match the backup (%v, %v) before restoring",
+			current.CarbUnits, current.GlucoseUnits, b.CarbUnits, b.GlucoseUnits))
+		return diffs
+	}
+	setters := map[string]func(){
+		"maxbasal": func() { pump.SetMaxBasal(b.Settings.MaxBasal) },
+		"maxbolus": func() { pump.SetMaxBolus(b.Settings.MaxBolus) },
+		"basal":    func() { pump.SetBasalRates(b.BasalRates) },
+		"patterna": func() { pump.SetBasalPatternA(b.BasalPatternA) },
+		"patternb": func() { pump.SetBasalPatternB(b.BasalPatternB) },
+		"pattern":  func() { pump.SelectBasalPattern(b.Settings.SelectedPattern) },
+	}
+	lowered := map[string]bool{
+		"maxbasal": b.Settings.MaxBasal < current.Settings.MaxBasal,
+		"maxbolus": b.Settings.MaxBolus < current.Settings.MaxBolus,
+	}
+	var first, last []func()
+	for _, d := range diffs {
+		if d.ReadOnly {
+			continue
+		}
+		if lowered[d.Name] {
+			last = append(last, setters[d.Name])
+		} else {
+			first = append(first, setters[d.Name])
+		}
+	}
+	for _, set := range append(first, last...) {
+		set()
+		if pump.Error() != nil {
+			break
+		}
+	}
+	return diffs
+}
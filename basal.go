@@ -2,7 +2,6 @@ package medtronic
 
 import (
 	"fmt"
-	"log"
 	"time"
 )
 
@@ -89,7 +88,13 @@ func (pump *Pump) setBasalSchedule(cmd Command, s BasalRateSchedule) {
 		pump.SetError(err)
 		return
 	}
-	pump.ExtendedRequest(setBasalRates, data...)
+	if !pump.allow(GuardRequest{Command: cmd, Schedule: s}) {
+		return
+	}
+	for i, v := range s {
+		pump.logRounding("basal rate", v.Rate, twoByteInsulinLE(data[3*i:3*i+2]))
+	}
+	pump.ExtendedRequest(cmd, data...)
 }
 
 // SetBasalRates sets the pump's basal rate schedule.
@@ -107,6 +112,19 @@ func (pump *Pump) SetBasalPatternB(s BasalRateSchedule) {
 	pump.setBasalSchedule(setBasalPatternB, s)
 }
 
+// SelectBasalPattern selects the pump's basal pattern
+// (0 for the standard schedule, 1 for pattern A, 2 for pattern B).
+func (pump *Pump) SelectBasalPattern(pattern int) {
+	if pattern < 0 || pattern > 2 {
+		pump.SetError(fmt.Errorf("invalid basal pattern (%d)", pattern))
+		return
+	}
+	if !pump.allow(GuardRequest{Command: selectBasalPattern}) {
+		return
+	}
+	pump.Execute(selectBasalPattern, byte(pattern))
+}
+
 func encodeBasalRate(kind string, rate Insulin, family Family) (uint16, error) {
 	if rate < 0 {
 		return 0, fmt.Errorf("%s rate (%d) is negative", kind, rate)
@@ -126,9 +144,6 @@ func encodeBasalRate(kind string, rate Insulin, family Family) (uint16, error) {
 		res = 100
 	}
 	actual := (rate / res) * res
-	if actual != rate {
-		log.Printf("rounding %s rate from %v to %v", kind, rate, actual)
-	}
 	// Encode the rounded value using 25 milliUnits/stroke.
 	m := milliUnitsPerStroke(23)
 	return uint16(actual / m), nil
@@ -2,7 +2,6 @@ package medtronic
 
 import (
 	"fmt"
-	"log"
 )
 
 const (
@@ -17,6 +16,11 @@ func (pump *Pump) Bolus(amount Insulin) {
 		pump.SetError(err)
 		return
 	}
+	actual := Insulin(n) * milliUnitsPerStroke(family)
+	if !pump.allow(GuardRequest{Command: bolus, Amount: actual}) {
+		return
+	}
+	pump.logRounding("bolus", amount, actual)
 	if family <= 22 {
 		pump.Execute(bolus, uint8(n))
 	} else {
@@ -28,7 +32,7 @@ func encodeBolus(amount Insulin, family Family) (uint16, error) {
 	if amount < 0 {
 		return 0, fmt.Errorf("bolus amount (%d) is negative", amount)
 	}
-	if amount > maxBasal {
+	if amount > maxBolus {
 		return 0, fmt.Errorf("bolus amount (%d) is too large", amount)
 	}
 	// Round the amount to the pump's delivery resolution.
@@ -43,9 +47,6 @@ func encodeBolus(amount Insulin, family Family) (uint16, error) {
 		res = 100
 	}
 	actual := (amount / res) * res
-	if actual != amount {
-		log.Printf("rounding bolus from %v to %v", amount, actual)
-	}
 	// Encode the rounded value using the family-specific units/stroke.
 	m := milliUnitsPerStroke(family)
 	return uint16(actual / m), nil
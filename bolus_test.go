@@ -38,3 +38,16 @@ func TestEncodeBolus(t *testing.T) {
 		})
 	}
 }
+
+func TestEncodeBolusErrors(t *testing.T) {
+	for _, amount := range []Insulin{-100, maxBolus + 100, maxBasal} {
+		_, err := encodeBolus(amount, 23)
+		if err == nil {
+			t.Errorf("encodeBolus(%d, 23) did not return an error", amount)
+		}
+	}
+	_, err := encodeBolus(maxBolus, 23)
+	if err != nil {
+		t.Errorf("encodeBolus(%d, 23) raised error (%v)", maxBolus, err)
+	}
+}
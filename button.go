@@ -16,5 +16,8 @@ const (
 
 // Button sends the button-press to the pump.
 func (pump *Pump) Button(b PumpButton) {
+	if !pump.allow(GuardRequest{Command: button}) {
+		return
+	}
 	pump.Execute(button, byte(b))
 }
@@ -0,0 +1,275 @@
+// Package capture records radio traffic to a file and replays it,
+// so that a session with a real pump can be turned into a deterministic test.
+//
+// A capture file contains one JSON-encoded Exchange per line.
+package capture
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ecc1/radio"
+)
+
+// Operation identifies the radio.Interface method that produced an exchange.
+type Operation string
+
+// Recorded operations.
+const (
+	Send           Operation = "Send"
+	Receive        Operation = "Receive"
+	SendAndReceive Operation = "SendAndReceive"
+)
+
+// Exchange represents a single recorded radio operation.
+type Exchange struct {
+	Time      time.Time
+	Op        Operation
+	Frequency uint32
+	Timeout   time.Duration `json:",omitempty"`
+	Sent      []byte        `json:",omitempty"`
+	Received  []byte        `json:",omitempty"`
+	RSSI      int           `json:",omitempty"`
+}
+
+// Recorder wraps a radio.Interface and records every exchange.
+type Recorder struct {
+	radio.Interface
+	mu  sync.Mutex
+	w   io.WriteCloser
+	enc *json.Encoder
+	err error
+}
+
+// NewRecorder returns a radio.Interface that forwards to r
+// and writes each exchange to w.
+func NewRecorder(r radio.Interface, w io.WriteCloser) *Recorder {
+	return &Recorder{Interface: r, w: w, enc: json.NewEncoder(w)}
+}
+
+// Record returns a radio.Interface that forwards to r
+// and records each exchange in the given file.
+func Record(r radio.Interface, file string) (*Recorder, error) {
+	f, err := os.Create(file)
+	if err != nil {
+		return nil, err
+	}
+	return NewRecorder(r, f), nil
+}
+
+func (rec *Recorder) write(e Exchange) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if rec.err != nil {
+		return
+	}
+	e.Time = time.Now()
+	e.Frequency = rec.Interface.Frequency()
+	rec.err = rec.enc.Encode(e)
+}
+
+// Send transmits the given packet and records it.
+func (rec *Recorder) Send(data []byte) {
+	rec.Interface.Send(data)
+	rec.write(Exchange{Op: Send, Sent: data})
+}
+
+// Receive listens for an incoming packet and records the result.
+func (rec *Recorder) Receive(timeout time.Duration) ([]byte, int) {
+	p, rssi := rec.Interface.Receive(timeout)
+	rec.write(Exchange{Op: Receive, Timeout: timeout, Received: p, RSSI: rssi})
+	return p, rssi
+}
+
+// SendAndReceive transmits the given packet, listens for a response,
+// and records the exchange.
+func (rec *Recorder) SendAndReceive(data []byte, timeout time.Duration) ([]byte, int) {
+	p, rssi := rec.Interface.SendAndReceive(data, timeout)
+	rec.write(Exchange{Op: SendAndReceive, Timeout: timeout, Sent: data, Received: p, RSSI: rssi})
+	return p, rssi
+}
+
+// Close closes the underlying radio and the capture file.
+func (rec *Recorder) Close() {
+	rec.Interface.Close()
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	err := rec.w.Close()
+	if rec.err == nil {
+		rec.err = err
+	}
+}
+
+// Err returns the first error encountered while writing the capture, if any.
+func (rec *Recorder) Err() error {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return rec.err
+}
+
+// ReadExchanges reads a sequence of exchanges in capture file format.
+func ReadExchanges(r io.Reader) ([]Exchange, error) {
+	var exchanges []Exchange
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 1<<20)
+	for s.Scan() {
+		line := bytes.TrimSpace(s.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var e Exchange
+		err := json.Unmarshal(line, &e)
+		if err != nil {
+			return exchanges, err
+		}
+		exchanges = append(exchanges, e)
+	}
+	return exchanges, s.Err()
+}
+
+// MismatchError indicates that a replayed session diverged from the capture.
+type MismatchError struct {
+	Index    int
+	Expected Exchange
+	Op       Operation
+	Sent     []byte
+}
+
+func (e MismatchError) Error() string {
+	if e.Op != e.Expected.Op {
+		return fmt.Sprintf("capture exchange %d: %s called instead of %s", e.Index, e.Op, e.Expected.Op)
+	}
+	return fmt.Sprintf("capture exchange %d: sent % X instead of % X", e.Index, e.Sent, e.Expected.Sent)
+}
+
+// ErrEndOfCapture indicates that a replayed session continued past the end of the capture.
+var ErrEndOfCapture = fmt.Errorf("end of capture")
+
+// Replayer is a radio.Interface that returns the responses from a capture.
+// Each operation must match the next recorded exchange,
+// including the contents of transmitted packets.
+type Replayer struct {
+	mu        sync.Mutex
+	exchanges []Exchange
+	next      int
+	freq      uint32
+	err       error
+}
+
+// NewReplayer returns a radio.Interface that replays the given exchanges.
+func NewReplayer(exchanges []Exchange) *Replayer {
+	return &Replayer{exchanges: exchanges}
+}
+
+// Replay returns a radio.Interface that replays the exchanges in the given capture file.
+func Replay(file string) (*Replayer, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	exchanges, err := ReadExchanges(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	return NewReplayer(exchanges), nil
+}
+
+// Remaining returns the number of exchanges that have not yet been replayed.
+func (rep *Replayer) Remaining() int {
+	rep.mu.Lock()
+	defer rep.mu.Unlock()
+	return len(rep.exchanges) - rep.next
+}
+
+func (rep *Replayer) replay(op Operation, data []byte) ([]byte, int) {
+	rep.mu.Lock()
+	defer rep.mu.Unlock()
+	if rep.next >= len(rep.exchanges) {
+		rep.err = ErrEndOfCapture
+		return nil, 0
+	}
+	e := rep.exchanges[rep.next]
+	if op != e.Op || !bytes.Equal(data, e.Sent) {
+		rep.err = MismatchError{Index: rep.next, Expected: e, Op: op, Sent: data}
+		return nil, 0
+	}
+	rep.next++
+	return e.Received, e.RSSI
+}
+
+// Init initializes the radio device.
+func (rep *Replayer) Init(freq uint32) {
+	rep.SetFrequency(freq)
+}
+
+// Reset resets the radio device.
+func (rep *Replayer) Reset() {}
+
+// Close closes the radio device.
+func (rep *Replayer) Close() {}
+
+// Frequency returns the radio's current frequency, in Hertz.
+func (rep *Replayer) Frequency() uint32 {
+	rep.mu.Lock()
+	defer rep.mu.Unlock()
+	return rep.freq
+}
+
+// SetFrequency sets the radio to the given frequency, in Hertz.
+func (rep *Replayer) SetFrequency(freq uint32) {
+	rep.mu.Lock()
+	rep.freq = freq
+	rep.mu.Unlock()
+}
+
+// Send replays the transmission of the given packet.
+func (rep *Replayer) Send(data []byte) {
+	rep.replay(Send, data)
+}
+
+// Receive replays the reception of a packet.
+func (rep *Replayer) Receive(timeout time.Duration) ([]byte, int) {
+	return rep.replay(Receive, nil)
+}
+
+// SendAndReceive replays the transmission of the given packet
+// and returns the recorded response.
+func (rep *Replayer) SendAndReceive(data []byte, timeout time.Duration) ([]byte, int) {
+	return rep.replay(SendAndReceive, data)
+}
+
+// State returns the radio's current state as a string.
+func (rep *Replayer) State() string {
+	return "idle"
+}
+
+// Error returns the error state of the radio device.
+func (rep *Replayer) Error() error {
+	rep.mu.Lock()
+	defer rep.mu.Unlock()
+	return rep.err
+}
+
+// SetError sets the error state of the radio device.
+func (rep *Replayer) SetError(err error) {
+	rep.mu.Lock()
+	rep.err = err
+	rep.mu.Unlock()
+}
+
+// Name returns the radio's name.
+func (rep *Replayer) Name() string {
+	return "replay"
+}
+
+// Device returns the pathname of the radio's device.
+func (rep *Replayer) Device() string {
+	return "capture"
+}
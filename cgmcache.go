@@ -0,0 +1,147 @@
+package medtronic
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+// CGMCache records how far the CGM history has been read,
+// so that SyncCGMHistory only needs to decode records added since then.
+type CGMCache struct {
+	Page   int       // most recent glucose page read
+	Length int       // number of bytes already read on that page
+	Anchor time.Time // time of the most recent timed record, for relative records that follow it
+}
+
+// ReadCGMCache reads a CGM cache from a JSON file.
+// A nonexistent file results in an empty cache.
+func ReadCGMCache(file string) (*CGMCache, error) {
+	cache := &CGMCache{}
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, cache)
+	if err != nil {
+		return nil, err
+	}
+	return cache, nil
+}
+
+// Write writes the CGM cache to a JSON file.
+func (cache *CGMCache) Write(file string) error {
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, data, 0644)
+}
+
+// update records the state of the current page and its most recent timed record.
+func (cache *CGMCache) update(page int, length int, records CGMHistory) {
+	cache.Page = page
+	cache.Length = length
+	for _, r := range records {
+		if r.Type.isRelative() || r.Type == CGMTimestamp {
+			cache.Anchor = r.Time
+			break
+		}
+	}
+}
+
+// SyncCGMHistory returns the CGM records added since the cache was last updated,
+// excluding any that did not occur after the specified time.
+// Only the current glucose page and any pages started since the previous sync are downloaded,
+// and only the records that follow the previously read ones are decoded,
+// using the cached anchor to assign times to relative records.
+// If the cache is empty or out of date, the history is scanned as in CGMHistory.
+func (pump *Pump) SyncCGMHistory(cache *CGMCache, since time.Time) CGMHistory {
+	n := pump.CGMCurrentGlucosePage()
+	if pump.Error() != nil {
+		return nil
+	}
+	if cache.Anchor.IsZero() || n < cache.Page || n-cache.Page >= MaxGlucosePages {
+		return pump.rescanCGMHistory(cache, n, since)
+	}
+	next := *cache
+	var results CGMHistory
+	for page := cache.Page; page <= n; page++ {
+		data := pump.GlucosePage(page)
+		if pump.Error() != nil {
+			return nil
+		}
+		used := len(bytes.TrimRight(data, "\x00"))
+		start := 0
+		if page == cache.Page {
+			start = cache.Length
+			if used < start {
+				pump.Logger().Info("CGM cache discarded", "page", page)
+				return pump.rescanCGMHistory(cache, n, since)
+			}
+		}
+		records, err := decodeCGMTail(data[start:used], next.Anchor)
+		if err != nil {
+			pump.SetError(err)
+			return nil
+		}
+		next.update(page, used, records)
+		results = append(records, results...)
+	}
+	*cache = next
+	return results[:findCGMSince(results, since)]
+}
+
+func (pump *Pump) rescanCGMHistory(cache *CGMCache, n int, since time.Time) CGMHistory {
+	results, current, used := pump.cgmHistoryFrom(n, since)
+	if pump.Error() != nil {
+		return results
+	}
+	*cache = CGMCache{}
+	cache.update(n, used, current)
+	return results
+}
+
+// decodeCGMTail decodes records that were added to a glucose page after the anchor time
+// and returns them in reverse chronological order (most recent first).
+// Relative records before any timestamp in the new data are assumed to follow the anchor
+// at 5-minute intervals.
+func decodeCGMTail(tail []byte, anchor time.Time) (CGMHistory, error) {
+	if len(tail) == 0 {
+		return nil, nil
+	}
+	data := make([]byte, len(tail))
+	copy(data, tail)
+	reverseBytes(data)
+	t, _, err := initialTimestamp(data)
+	if err == ErrorNeedsTimestamp {
+		t = anchor.Add(time.Duration(relativeBeforeTimestamp(data)) * 5 * time.Minute)
+	} else if err != nil {
+		return nil, err
+	}
+	copy(data, tail)
+	records, _, err := DecodeCGMHistory(data, t)
+	return records, err
+}
+
+// relativeBeforeTimestamp returns the number of relative records
+// that occur before the first timestamp in reversed CGM data.
+func relativeBeforeTimestamp(data []byte) int {
+	n := 0
+	for len(data) != 0 {
+		r, err := DecodeCGMRecord(data)
+		if err != nil || r.Type == CGMTimestamp {
+			break
+		}
+		if r.Type.isRelative() {
+			n++
+		}
+		data = data[len(r.Data):]
+	}
+	return n
+}
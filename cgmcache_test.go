@@ -0,0 +1,84 @@
+package medtronic
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/thecubic/medtronic/simulator"
+)
+
+// storedCGMRecord returns a CGM record in the order in which it is stored in a page.
+func storedCGMRecord(hex string) []byte {
+	data := parseBytes(hex)
+	reverseBytes(data)
+	return data
+}
+
+func TestSyncCGMHistory(t *testing.T) {
+	config := simulator.DefaultConfig(testPumpID, "523")
+	pages := map[int][]byte{
+		// Timestamp record for 2016-02-08 20:54 (pageEnd).
+		0: storedCGMRecord("0814b62810"),
+	}
+	config.GlucosePages = pages
+	pump := simulatedPump(t, config)
+	anchor := parseTime("2016-02-08T20:54")
+	glucose := bytes.Repeat([]byte{0x35}, 10)
+	pages[0] = append(pages[0], glucose...)
+	file := filepath.Join(t.TempDir(), "cgm.json")
+	cases := []struct {
+		current []byte // records to append to the current page
+		next    []byte // records for a new page
+		count   int    // number of new relative records
+	}{
+		{nil, nil, 10}, // empty cache
+		{glucose[:5], nil, 5},
+		{nil, nil, 0},
+		{glucose[:2], glucose[:3], 5},
+		{glucose[:1], nil, 1},
+	}
+	total := 0
+	for _, c := range cases {
+		n := len(pages) - 1
+		pages[n] = append(pages[n], c.current...)
+		if c.next != nil {
+			pages[n+1] = c.next
+		}
+		cache, err := ReadCGMCache(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		empty := *cache == CGMCache{}
+		got := pump.SyncCGMHistory(cache, time.Time{})
+		if pump.Error() != nil {
+			t.Fatal(pump.Error())
+		}
+		total += c.count
+		var want CGMHistory
+		for i := 0; i < c.count; i++ {
+			r := CGMRecord{Type: CGMGlucose, Data: []byte{0x35}, Glucose: 106}
+			r.Time = anchor.Add(time.Duration(total-i) * 5 * time.Minute)
+			want = append(want, r)
+		}
+		if empty {
+			// The initial sync also returns the timestamp.
+			want = pump.CGMHistory(time.Time{})
+			if pump.Error() != nil {
+				t.Fatal(pump.Error())
+			}
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("SyncCGMHistory returned %+v, want %+v", got, want)
+		}
+		if cache.Page != len(pages)-1 {
+			t.Errorf("cache page = %d, want %d", cache.Page, len(pages)-1)
+		}
+		err = cache.Write(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -1,45 +1,93 @@
 package medtronic
 
 import (
-	"log"
+	"bytes"
 	"time"
 )
 
 // CGMHistory returns the CGM records since the specified time.
+// If the current page has no timestamp, the error is ErrorNeedsTimestamp
+// unless writing a sensor timestamp has been allowed with SetWriteCGMTimestamp.
 func (pump *Pump) CGMHistory(since time.Time) CGMHistory {
 	n := pump.CGMCurrentGlucosePage()
+	if pump.Error() != nil {
+		return nil
+	}
+	results, _, _ := pump.cgmHistoryFrom(n, since)
+	return results
+}
+
+// SetWriteCGMTimestamp sets whether CGM history functions
+// may write a sensor timestamp when the current page needs one.
+// Since this changes the pump's CGM history, it is not done by default.
+func (pump *Pump) SetWriteCGMTimestamp(allow bool) {
+	pump.writeCGMTimestamp = allow
+}
+
+// writeTimestamp writes a sensor timestamp if that has been allowed,
+// and returns true if it was written successfully.
+func (pump *Pump) writeTimestamp(page int) bool {
+	if !pump.writeCGMTimestamp {
+		return false
+	}
+	pump.Logger().Info("writing CGM timestamp and rescanning", "page", page)
+	pump.CGMWriteTimestamp()
+	return pump.Error() == nil
+}
+
+// cgmHistoryFrom scans backwards from glucose page n and returns the records since the specified time.
+// It also returns all the records in page n and the number of bytes in use on that page.
+func (pump *Pump) cgmHistoryFrom(n int, since time.Time) (CGMHistory, CGMHistory, int) {
+	var results, current CGMHistory
+	used := 0
+	pump.scanGlucosePages(n, func(page int, length int, records CGMHistory) bool {
+		if page == n {
+			current = records
+			used = length
+		}
+		i := findCGMSince(records, since)
+		results = append(results, records[:i]...)
+		if i < len(records) {
+			pump.logScanStop("CGM history", page, records[i].Time)
+			return false
+		}
+		return true
+	})
+	return results, current, used
+}
+
+// scanGlucosePages downloads and decodes glucose pages backwards from page n,
+// using the timestamps in each page to assign times to the records in the preceding one.
+// For each page, fn is called with the number of bytes in use and the decoded records,
+// until it returns false or an error occurs.
+func (pump *Pump) scanGlucosePages(n int, fn func(page int, used int, records CGMHistory) bool) {
 	m := n - MaxGlucosePages + 1
 	if m < 0 {
 		m = 0
 	}
-	if pump.Error() != nil {
-		return nil
-	}
-	var results CGMHistory
 	var last time.Time
 	wroteTimestamp := false
 	for page := n; page >= m && pump.Error() == nil; page-- {
 		data := pump.GlucosePage(page)
+		if pump.Error() != nil {
+			break
+		}
+		used := len(bytes.TrimRight(data, "\x00"))
 		records, t, err := DecodeCGMHistory(data, last)
 		if err != nil {
-			if err == ErrorNeedsTimestamp && page == n && !wroteTimestamp {
+			if err == ErrorNeedsTimestamp && page == n && !wroteTimestamp && pump.writeTimestamp(page) {
 				// This is only tried once, for the first page.
-				log.Printf("writing CGM timestamp for page %d and rescanning", page)
-				pump.CGMWriteTimestamp()
 				wroteTimestamp = true
 				page = n + 1
 				continue
 			}
 			pump.SetError(err)
 		}
-		i := findCGMSince(records, since)
-		results = append(results, records[:i]...)
-		if i < len(records) {
+		if !fn(page, used, records) {
 			break
 		}
 		last = t
 	}
-	return results
 }
 
 // findCGMSince finds the first record that did not occur after the cutoff and returns its index,
@@ -48,7 +96,6 @@ func findCGMSince(records CGMHistory, cutoff time.Time) int {
 	for i, r := range records {
 		t := r.Time
 		if !t.IsZero() && !t.After(cutoff) {
-			log.Printf("stopping CGM history scan at %s", t.Format(UserTimeLayout))
 			return i
 		}
 	}
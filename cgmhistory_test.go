@@ -0,0 +1,36 @@
+package medtronic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/thecubic/medtronic/simulator"
+)
+
+func TestCGMWriteTimestamp(t *testing.T) {
+	config := simulator.DefaultConfig(testPumpID, "523")
+	config.GlucosePages = map[int][]byte{0: {0x35, 0x35, 0x35}}
+	pump := simulatedPump(t, config)
+	stats := NewStats()
+	pump.SetMetrics(stats)
+	cases := []struct {
+		allow  bool
+		writes int
+	}{
+		{false, 0},
+		{true, 1},
+	}
+	for _, c := range cases {
+		pump.SetWriteCGMTimestamp(c.allow)
+		pump.CGMHistory(time.Time{})
+		// The simulator accepts the command but does not add a timestamp.
+		if pump.Error() != ErrorNeedsTimestamp {
+			t.Errorf("CGMHistory returned error %v, want %v", pump.Error(), ErrorNeedsTimestamp)
+		}
+		pump.SetError(nil)
+		writes := stats.Command(cgmWriteTimestamp).Outcomes[Success]
+		if writes != c.writes {
+			t.Errorf("SetWriteCGMTimestamp(%v): %d timestamp writes, want %d", c.allow, writes, c.writes)
+		}
+	}
+}
@@ -49,5 +49,8 @@ func (pump *Pump) CGMCurrentGlucosePage() int {
 
 // CGMWriteTimestamp writes a new sensor timestamp to the CGM history.
 func (pump *Pump) CGMWriteTimestamp() {
+	if !pump.allow(GuardRequest{Command: cgmWriteTimestamp}) {
+		return
+	}
 	pump.Execute(cgmWriteTimestamp)
 }
@@ -37,11 +37,12 @@ const (
 type (
 	// CGMRecord represents a CGM record.
 	CGMRecord struct {
-		Type    CGMRecordType
-		Data    []byte
-		Time    time.Time
-		Glucose int    `json:",omitempty"`
-		Value   string `json:",omitempty"`
+		Type      CGMRecordType
+		Data      []byte
+		Time      time.Time
+		Glucose   int     `json:",omitempty"`
+		CalFactor float64 `json:",omitempty"` // mg/dL per nA (experimental)
+		Value     string  `json:",omitempty"`
 	}
 
 	// CGMHistory represents a sequence of CGM records.
@@ -69,8 +70,8 @@ var cgmDecodeInfo = map[CGMRecordType]decodeInfo{
 	CGMTimeChange:    {5, nil},
 	CGMSync:          {5, decodeCGMSync},
 	CGMCalBGForGH:    {6, decodeCGMCalBGForGH},
-	CGMCalFactor:     {7, nil},
-	CGMEvent10:       {8, nil},
+	CGMCalFactor:     {7, decodeCGMCalFactor},
+	CGMEvent10:       {8, decodeCGMEvent10},
 	CGMGlucose:       {1, decodeCGMGlucose},
 }
 
@@ -149,6 +150,17 @@ func decodeCGMCalBGForGH(r *CGMRecord) {
 	r.Glucose = int(r.Data[5])
 }
 
+// The scale of the calibration factor is assumed and has not been verified
+// (see calFactorScale), so CalFactor is experimental.
+func decodeCGMCalFactor(r *CGMRecord) {
+	r.CalFactor = calFactorValue(int(twoByteUint(r.Data[5:7])))
+}
+
+// The meaning of the remaining bytes is unknown.
+func decodeCGMEvent10(r *CGMRecord) {
+	r.Value = fmt.Sprintf("%X", r.Data[5:])
+}
+
 func decodeCGMGlucose(r *CGMRecord) {
 	r.Glucose = 2 * int(r.Data[0])
 }
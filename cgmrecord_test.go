@@ -86,8 +86,14 @@ func TestDecodeCGMRecord(t *testing.T) {
 			Glucose: 160,
 		}},
 		{parseBytes("0f4f67130f128c"), CGMRecord{
-			Type: CGMCalFactor,
-			Time: parseTime("2015-05-19T15:39"),
+			Type:      CGMCalFactor,
+			Time:      parseTime("2015-05-19T15:39"),
+			CalFactor: 4.748,
+		}},
+		{parseBytes("104f67130f010203"), CGMRecord{
+			Type:  CGMEvent10,
+			Time:  parseTime("2015-05-19T15:39"),
+			Value: "010203",
 		}},
 		{parseBytes("0402"), CGMRecord{
 			Type: CGMPacket,
@@ -29,6 +29,9 @@ func (pump *Pump) Clock() time.Time {
 
 // SetClock sets the pump's clock to the given time.
 func (pump *Pump) SetClock(t time.Time) {
+	if !pump.allow(GuardRequest{Command: setClock}) {
+		return
+	}
 	year := marshalUint16(uint16(t.Year()))
 	pump.Execute(setClock,
 		byte(t.Hour()),
@@ -0,0 +1,115 @@
+package medtronic
+
+import (
+	"time"
+)
+
+const (
+	// Records may appear slightly out of order (for example, a prime
+	// recorded after the basal profile start that follows it).
+	// A larger backward jump means the clock was set back without a record of it.
+	clockTolerance = 5 * time.Minute
+)
+
+// A TimeCorrector rewrites the times of history records into UTC,
+// accounting for changes to the pump's clock.
+// The pump's clock has no notion of time zones or daylight saving time,
+// so its readings are treated as wall clock times that differ from UTC
+// by an offset that only changes when the clock is set.
+type TimeCorrector struct {
+	offset    time.Duration // from the pump's wall clock time to UTC
+	newTime   time.Time     // wall clock time of a NewTime record not yet matched with its ChangeTime record
+	last      time.Time     // corrected time of the previous record
+	uncertain bool
+}
+
+// NewTimeCorrector returns a TimeCorrector for a pump whose clock read the given time
+// at the specified actual time.
+func NewTimeCorrector(clock time.Time, now time.Time) *TimeCorrector {
+	return &TimeCorrector{offset: now.Sub(wallClock(clock))}
+}
+
+// wallClock returns the pump clock reading t with its location replaced by UTC,
+// so that intervals between readings are not affected by daylight saving time
+// in the location it was decoded in.
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// Correct rewrites the times of history records, which must be in reverse chronological order
+// (most recent first) and must precede any records passed to previous calls.
+// The corrected times are in UTC and never increase from one record to the next,
+// except for the dates of daily totals.
+// Records older than a ChangeTime/NewTime pair are shifted by the amount the clock was changed.
+// If the clock was set back without such a record (for example, when the pump was reset),
+// older records are shifted so that they remain in order.
+// Correct returns a slice indicating which records have uncertain times:
+// those without a time, and those older than a clock change whose amount is unknown.
+func (c *TimeCorrector) Correct(records History) []bool {
+	uncertain := make([]bool, len(records))
+	for i := range records {
+		r := &records[i]
+		if r.Time.IsZero() {
+			uncertain[i] = true
+			continue
+		}
+		t := wallClock(r.Time)
+		switch r.Type() {
+		case DailyTotal, DailyTotal515, DailyTotal522, DailyTotal523:
+			// These are dates, which are not in order with the other records.
+			r.Time = t.Add(c.offset)
+			uncertain[i] = c.uncertain
+			continue
+		case NewTime:
+			c.unmatched()
+			c.newTime = t
+		case ChangeTime:
+			if c.newTime.IsZero() {
+				c.uncertain = true
+			} else {
+				c.offset += c.newTime.Sub(t)
+				c.newTime = time.Time{}
+			}
+		default:
+			c.unmatched()
+		}
+		corrected := t.Add(c.offset).UTC()
+		if !c.last.IsZero() && corrected.After(c.last) {
+			if corrected.Sub(c.last) > clockTolerance {
+				c.offset -= corrected.Sub(c.last)
+				c.uncertain = true
+			}
+			corrected = c.last
+		}
+		r.Time = corrected
+		c.last = corrected
+		uncertain[i] = c.uncertain
+	}
+	return uncertain
+}
+
+// unmatched handles a NewTime record that is not followed by a ChangeTime record.
+func (c *TimeCorrector) unmatched() {
+	if !c.newTime.IsZero() {
+		c.uncertain = true
+		c.newTime = time.Time{}
+	}
+}
+
+// CorrectedHistory returns the history records since the specified time,
+// with their times corrected by a TimeCorrector using the pump's clock,
+// along with a slice indicating which records have uncertain times.
+// Unlike History, the cutoff is compared with the corrected times,
+// so records are not missed or included because of clock changes.
+func (pump *Pump) CorrectedHistory(since time.Time) (History, []bool) {
+	clock := pump.Clock()
+	if pump.Error() != nil {
+		return nil, nil
+	}
+	c := NewTimeCorrector(clock, time.Now())
+	var uncertain []bool
+	records := pump.history(since, func(records History) {
+		uncertain = append(uncertain, c.Correct(records)...)
+	})
+	return records, uncertain[:len(records)]
+}
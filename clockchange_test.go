@@ -0,0 +1,110 @@
+package medtronic
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestTimeCorrector(t *testing.T) {
+	type timeRecord struct {
+		t         HistoryRecordType
+		pumpTime  string
+		corrected string
+		uncertain bool
+	}
+	cases := []struct {
+		now     string
+		clock   string
+		records []timeRecord
+	}{
+		{
+			"2018-06-20T12:00", "2018-06-20T12:00",
+			[]timeRecord{
+				{BGCapture, "2018-06-20T11:00", "2018-06-20T11:00", false},
+				{DailyTotal, "2018-06-19T00:00", "2018-06-19T00:00", false},
+				{BGCapture, "2018-06-19T22:00", "2018-06-19T22:00", false},
+			},
+		},
+		{
+			// Pump clock 30 minutes slow.
+			"2018-06-20T12:00", "2018-06-20T11:30",
+			[]timeRecord{
+				{BGCapture, "2018-06-20T11:00", "2018-06-20T11:30", false},
+			},
+		},
+		{
+			// Clock set forward by an hour.
+			"2018-06-20T12:00", "2018-06-20T12:00",
+			[]timeRecord{
+				{BGCapture, "2018-06-20T11:30", "2018-06-20T11:30", false},
+				{NewTime, "2018-06-20T11:00", "2018-06-20T11:00", false},
+				{ChangeTime, "2018-06-20T10:00", "2018-06-20T11:00", false},
+				{BGCapture, "2018-06-20T09:30", "2018-06-20T10:30", false},
+			},
+		},
+		{
+			// Clock set back by an hour.
+			"2018-06-20T12:00", "2018-06-20T12:00",
+			[]timeRecord{
+				{NewTime, "2018-06-20T10:00", "2018-06-20T10:00", false},
+				{ChangeTime, "2018-06-20T11:00", "2018-06-20T10:00", false},
+				{BGCapture, "2018-06-20T10:30", "2018-06-20T09:30", false},
+			},
+		},
+		{
+			// Pump clock not changed for daylight saving time.
+			"2018-03-11T11:00", "2018-03-11T10:00",
+			[]timeRecord{
+				{BGCapture, "2018-03-11T03:30", "2018-03-11T04:30", false},
+				{BGCapture, "2018-03-11T01:30", "2018-03-11T01:30", false},
+			},
+		},
+		{
+			// Slightly out of order.
+			"2018-06-20T12:00", "2018-06-20T12:00",
+			[]timeRecord{
+				{BasalProfileStart, "2018-06-20T11:00", "2018-06-20T11:00", false},
+				{Prime, "2018-06-20T11:01", "2018-06-20T11:00", false},
+				{Rewind, "2018-06-20T10:50", "2018-06-20T10:50", false},
+			},
+		},
+		{
+			// Clock set back without a record.
+			"2018-06-20T12:00", "2018-06-20T12:00",
+			[]timeRecord{
+				{BGCapture, "2018-06-20T10:00", "2018-06-20T10:00", false},
+				{BGCapture, "2018-06-20T11:00", "2018-06-20T10:00", true},
+				{BGCapture, "2018-06-20T10:30", "2018-06-20T09:30", true},
+			},
+		},
+		{
+			// Unmatched clock change records.
+			"2018-06-20T12:00", "2018-06-20T12:00",
+			[]timeRecord{
+				{NewTime, "2018-06-20T11:00", "2018-06-20T11:00", false},
+				{BGCapture, "2018-06-20T10:00", "2018-06-20T10:00", true},
+				{ChangeTime, "2018-06-20T09:00", "2018-06-20T09:00", true},
+			},
+		},
+	}
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("case%d", i+1), func(t *testing.T) {
+			tc := NewTimeCorrector(parseTime(c.clock), parseTime(c.now))
+			records := make(History, len(c.records))
+			for j, r := range c.records {
+				records[j] = HistoryRecord{Data: []byte{byte(r.t)}, Time: parseTime(r.pumpTime)}
+			}
+			// Pass the records one at a time, as if each were on its own page.
+			var uncertain []bool
+			for j := range records {
+				uncertain = append(uncertain, tc.Correct(records[j:j+1])...)
+			}
+			for j, r := range c.records {
+				want := parseTime(r.corrected)
+				if !records[j].Time.Equal(want) || uncertain[j] != r.uncertain {
+					t.Errorf("%v record %d: Correct == %v (uncertain = %v), want %v (uncertain = %v)", r.t, j, records[j].Time, uncertain[j], want.UTC(), r.uncertain)
+				}
+			}
+		})
+	}
+}
@@ -15,6 +15,7 @@ var (
 	numHours  = flag.Int("n", 6, "number of `hours` of history to get")
 	nsFlag    = flag.Bool("ns", false, "format as Nightscout entries")
 	sinceFlag = flag.String("s", "", "get history since the specified `time` in RFC3339 format")
+	writeFlag = flag.Bool("w", false, "write a sensor timestamp if the current page needs one")
 )
 
 func main() {
@@ -36,6 +37,7 @@ func main() {
 	}
 	pump := medtronic.Open()
 	defer pump.Close()
+	pump.SetWriteCGMTimestamp(*writeFlag)
 	pump.Wakeup()
 	results := pump.CGMHistory(cutoff)
 	if *nsFlag {
@@ -33,6 +33,7 @@ var (
 	verboseFlag        = flag.Bool("v", false, "verbose mode")
 	jsonFile           = flag.String("f", "", "append results to JSON `file`")
 	jsonCutoff         = flag.Duration("k", 7*24*time.Hour, "maximum age of CGM entries to keep in JSON file")
+	writeFlag          = flag.Bool("w", false, "write a sensor timestamp if the current page needs one")
 
 	pump       *medtronic.Pump
 	cgmTime    time.Time
@@ -72,6 +73,7 @@ func main() {
 
 func getCGMInfo() {
 	pump = medtronic.Open()
+	pump.SetWriteCGMTimestamp(*writeFlag)
 	pump.Wakeup()
 	cgmTime = checkCGMClock()
 	if pump.Error() != nil {
@@ -0,0 +1,122 @@
+package pumpcmd
+
+import (
+	"fmt"
+	"strconv"
+)
+
+type (
+	// Arguments represents the formal and actual parameters for a command.
+	// Values are either strings (from the command line or a URL query)
+	// or JSON values (from an openaps argument file or a request body).
+	Arguments map[string]interface{}
+)
+
+// String returns the string value associated with the given key.
+func (args Arguments) String(key string) (string, error) {
+	arg := args[key]
+	s, ok := arg.(string)
+	if !ok {
+		return s, fmt.Errorf("%q argument must be a string", key)
+	}
+	return s, nil
+}
+
+// Float returns the float64 value associated with the given key.
+func (args Arguments) Float(key string) (float64, error) {
+	switch arg := args[key].(type) {
+	case float64:
+		return arg, nil
+	case string:
+		return strconv.ParseFloat(arg, 64)
+	default:
+		return 0, fmt.Errorf("%q parameter must be a number", key)
+	}
+}
+
+// Int returns the int value associated with the given key.
+func (args Arguments) Int(key string) (int, error) {
+	switch arg := args[key].(type) {
+	case float64:
+		return int(arg), nil
+	case string:
+		return strconv.Atoi(arg)
+	default:
+		return 0, fmt.Errorf("%q argument must be a number", key)
+	}
+}
+
+// Strings returns the []string value associated with the given key.
+func (args Arguments) Strings(key string) ([]string, error) {
+	switch arg := args[key].(type) {
+	case []string:
+		return arg, nil
+	case []interface{}:
+		a := make([]string, len(arg))
+		for i, si := range arg {
+			s, ok := si.(string)
+			if !ok {
+				return nil, fmt.Errorf("%q argument must be a list of strings", key)
+			}
+			a[i] = s
+		}
+		return a, nil
+	default:
+		return nil, fmt.Errorf("%q argument must be an array", key)
+	}
+}
+
+// CheckArgs checks that all the parameters of a command are present.
+func CheckArgs(name string, cmd Command, args Arguments) error {
+	if len(cmd.Params) == 0 {
+		if len(args) != 0 {
+			return fmt.Errorf("%s does not take any arguments", name)
+		}
+		return nil
+	}
+	for _, k := range cmd.Params {
+		_, present := args[k]
+		if !present {
+			return fmt.Errorf("%s: missing %q parameter", name, k)
+		}
+	}
+	return nil
+}
+
+// BindArgs binds a list of string arguments to the parameters of a command.
+func BindArgs(name string, cmd Command, argv []string) (Arguments, error) {
+	params := cmd.Params
+	if len(params) == 0 {
+		if len(argv) != 0 {
+			return nil, fmt.Errorf("%s does not take any arguments", name)
+		}
+		return nil, nil
+	}
+	variadic := cmd.Variadic
+	if !variadic && len(argv) != len(params) {
+		var p string
+		if len(params) != 1 {
+			p = "s"
+		}
+		return nil, fmt.Errorf("%s requires %d argument%s", name, len(params), p)
+	}
+	args := make(Arguments)
+	for i, k := range params {
+		if variadic && i == len(params)-1 {
+			// Bind all remaining args to this parameter.
+			if i < len(argv) {
+				args[k] = argv[i:]
+			} else {
+				args[k] = []string{}
+			}
+			break
+		}
+		if i >= len(argv) {
+			// Bind remaining parameters to "".
+			args[k] = ""
+			continue
+		}
+		args[k] = argv[i]
+	}
+	return args, nil
+}
@@ -0,0 +1,416 @@
+// Package pumpcmd provides the command table, argument handling,
+// and output formats shared by the mdt and mdtd programs.
+package pumpcmd
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/thecubic/medtronic"
+)
+
+type (
+	// Prog represents a function to be executed with the given arguments on the pump.
+	// Errors in the arguments are returned as a UsageError;
+	// errors communicating with the pump are left in the pump's error state.
+	Prog func(*medtronic.Pump, Arguments) (interface{}, error)
+
+	// Command specifies the function and formal parameters for a command.
+	// If Variadic is true, the last parameter is bound to a list of arguments
+	// (a JSON array of strings, or the remaining command-line arguments).
+	Command struct {
+		Cmd      Prog
+		Params   []string
+		Variadic bool
+	}
+)
+
+var (
+	// TODO: add per-command help
+
+	// Table maps each command name to its Command.
+	Table = map[string]Command{
+		"backup":        cmd(backup),
+		"basal":         cmd(basal),
+		"battery":       cmd(battery),
+		"bolus":         cmd(bolus, "units"),
+		"button":        cmdN(button, "keys"),
+		"carbratios":    cmd(carbRatios),
+		"carbunits":     cmd(carbUnits),
+		"cgm":           cmd(cgm, "hours"),
+		"clock":         cmd(clock),
+		"delivery":      cmd(delivery, "hours"),
+		"execute":       cmdN(execute, "command", "arguments"),
+		"firmware":      cmd(firmware),
+		"glucoseunits":  cmd(glucoseUnits),
+		"history":       cmd(history, "hours"),
+		"iob":           cmd(iob, "curve"),
+		"model":         cmd(model),
+		"pumpid":        cmd(pumpID),
+		"reservoir":     cmd(reservoir),
+		"resume":        cmd(resume),
+		"rssi":          cmd(rssi),
+		"sensitivities": cmd(sensitivities),
+		"setclock":      cmd(setClock, "time"),
+		"setmaxbasal":   cmd(setMaxBasal, "rate"),
+		"setmaxbolus":   cmd(setMaxBolus, "units"),
+		"settempbasal":  cmd(setTempBasal, "temp", "rate", "duration"),
+		"settings":      cmd(settings),
+		"status":        cmd(status),
+		"suspend":       cmd(suspend),
+		"targets":       cmd(targets),
+		"tempbasal":     cmd(tempBasal),
+		"wakeup":        cmd(wakeup),
+		"wizard":        cmd(wizard, "bg", "carbs"),
+	}
+)
+
+func cmd(prog Prog, params ...string) Command {
+	return Command{Cmd: prog, Params: params, Variadic: false}
+}
+
+func cmdN(prog Prog, params ...string) Command {
+	return Command{Cmd: prog, Params: params, Variadic: true}
+}
+
+// UsageError indicates invalid arguments for a command.
+type UsageError struct {
+	Name  string
+	Usage string
+	Err   error
+}
+
+func (e UsageError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Name, e.Err)
+}
+
+func cmdError(name string, msg string, err error) error {
+	return UsageError{Name: name, Usage: msg, Err: err}
+}
+
+// TODO: with argument to schedule progs, get schedule at that time
+
+func backup(pump *medtronic.Pump, _ Arguments) (interface{}, error) {
+	return pump.Backup(), nil
+}
+
+func basal(pump *medtronic.Pump, _ Arguments) (interface{}, error) {
+	return pump.BasalRates(), nil
+}
+
+func battery(pump *medtronic.Pump, _ Arguments) (interface{}, error) {
+	return pump.Battery(), nil
+}
+
+func bolus(pump *medtronic.Pump, args Arguments) (interface{}, error) {
+	f, err := args.Float("units")
+	if err != nil {
+		return nil, cmdError("bolus", "units", err)
+	}
+	amount := medtronic.Insulin(1000.0*f + 0.5)
+	log.Printf("performing bolus of %v units", amount)
+	pump.Bolus(amount)
+	return nil, nil
+}
+
+func button(pump *medtronic.Pump, args Arguments) (interface{}, error) {
+	v, err := args.Strings("keys")
+	if err != nil {
+		return nil, buttonUsage(err)
+	}
+	var buttons []medtronic.PumpButton
+	for _, s := range v {
+		b, found := buttonName[s]
+		if !found {
+			return nil, buttonUsage(fmt.Errorf("unknown pump button %q", s))
+		}
+		buttons = append(buttons, b)
+	}
+	for _, b := range buttons {
+		log.Printf("pressing %v", b)
+		pump.Button(b)
+		if pump.Error() != nil {
+			break
+		}
+	}
+	return nil, nil
+}
+
+var buttonName = map[string]medtronic.PumpButton{
+	"b":    medtronic.BolusButton,
+	"esc":  medtronic.EscButton,
+	"act":  medtronic.ActButton,
+	"up":   medtronic.UpButton,
+	"down": medtronic.DownButton,
+}
+
+func buttonUsage(err error) error {
+	return cmdError("button", "(b|esc|act|up|down) ...", err)
+}
+
+func carbRatios(pump *medtronic.Pump, _ Arguments) (interface{}, error) {
+	return pump.CarbRatios(), nil
+}
+
+func carbUnits(pump *medtronic.Pump, _ Arguments) (interface{}, error) {
+	return pump.CarbUnits(), nil
+}
+
+func cgm(pump *medtronic.Pump, args Arguments) (interface{}, error) {
+	since, err := sinceHours(args)
+	if err != nil {
+		return nil, cmdError("cgm", "hours", err)
+	}
+	return pump.CGMHistory(since), nil
+}
+
+func clock(pump *medtronic.Pump, _ Arguments) (interface{}, error) {
+	return pump.Clock(), nil
+}
+
+func execute(pump *medtronic.Pump, args Arguments) (interface{}, error) {
+	s, err := args.String("command")
+	if err != nil {
+		return nil, executeUsage(err)
+	}
+	c, err := strconv.ParseUint(s, 16, 8)
+	if err != nil {
+		return nil, executeUsage(err)
+	}
+	v, err := args.Strings("arguments")
+	if err != nil {
+		return nil, executeUsage(err)
+	}
+	var params []byte
+	for _, s := range v {
+		b, err := strconv.ParseUint(s, 16, 8)
+		if err != nil {
+			return nil, executeUsage(err)
+		}
+		params = append(params, byte(b))
+	}
+	cmd := medtronic.Command(c)
+	log.Printf("executing %v % X", cmd, params)
+	return pump.ExecuteRaw(cmd, params...), nil
+}
+
+func executeUsage(err error) error {
+	return cmdError("execute", "cmd [param ...]", err)
+}
+
+func firmware(pump *medtronic.Pump, _ Arguments) (interface{}, error) {
+	return pump.FirmwareVersion(), nil
+}
+
+func glucoseUnits(pump *medtronic.Pump, _ Arguments) (interface{}, error) {
+	return pump.GlucoseUnits(), nil
+}
+
+func history(pump *medtronic.Pump, args Arguments) (interface{}, error) {
+	since, err := sinceHours(args)
+	if err != nil {
+		return nil, cmdError("history", "hours", err)
+	}
+	return pump.History(since), nil
+}
+
+func delivery(pump *medtronic.Pump, args Arguments) (interface{}, error) {
+	since, err := sinceHours(args)
+	if err != nil {
+		return nil, cmdError("delivery", "hours", err)
+	}
+	return pump.DeliveryTimeline(since), nil
+}
+
+func iob(pump *medtronic.Pump, args Arguments) (interface{}, error) {
+	s, err := args.String("curve")
+	if err != nil {
+		return nil, iobUsage(err)
+	}
+	curve, found := curveName[s]
+	if !found {
+		return nil, iobUsage(fmt.Errorf("unknown insulin curve %q", s))
+	}
+	return pump.InsulinOnBoard(curve), nil
+}
+
+var curveName = map[string]medtronic.InsulinCurve{
+	"linear":      medtronic.LinearCurve,
+	"bilinear":    medtronic.BilinearCurve,
+	"exponential": medtronic.ExponentialCurve,
+	"walsh":       medtronic.WalshCurve,
+}
+
+func iobUsage(err error) error {
+	return cmdError("iob", "(linear|bilinear|exponential|walsh)", err)
+}
+
+func sinceHours(args Arguments) (time.Time, error) {
+	f, err := args.Float("hours")
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Now().Add(-time.Duration(f * float64(time.Hour))), nil
+}
+
+func model(pump *medtronic.Pump, _ Arguments) (interface{}, error) {
+	return pump.Model(), nil
+}
+
+func pumpID(pump *medtronic.Pump, _ Arguments) (interface{}, error) {
+	return pump.PumpID(), nil
+}
+
+func reservoir(pump *medtronic.Pump, _ Arguments) (interface{}, error) {
+	return pump.Reservoir(), nil
+}
+
+func resume(pump *medtronic.Pump, _ Arguments) (interface{}, error) {
+	log.Printf("resuming pump")
+	pump.Suspend(false)
+	return nil, nil
+}
+
+func rssi(pump *medtronic.Pump, _ Arguments) (interface{}, error) {
+	return pump.RSSI(), nil
+}
+
+func sensitivities(pump *medtronic.Pump, _ Arguments) (interface{}, error) {
+	return pump.InsulinSensitivities(), nil
+}
+
+func setClock(pump *medtronic.Pump, args Arguments) (interface{}, error) {
+	s, err := args.String("time")
+	if err != nil {
+		return nil, setClockUsage(err)
+	}
+	t, err := parseTime(s)
+	if err != nil {
+		return nil, setClockUsage(err)
+	}
+	log.Printf("setting pump clock to %s", t.Format(medtronic.UserTimeLayout))
+	pump.SetClock(t)
+	return nil, nil
+}
+
+func parseTime(date string) (time.Time, error) {
+	if date == "now" {
+		return time.Now(), nil
+	}
+	return time.ParseInLocation(medtronic.UserTimeLayout, date, time.Local)
+}
+
+func setClockUsage(err error) error {
+	return cmdError("setclock", "YYYY-MM-DD HH:MM:SS (or \"now\")", err)
+}
+
+func setMaxBasal(pump *medtronic.Pump, args Arguments) (interface{}, error) {
+	f, err := args.Float("rate")
+	if err != nil {
+		return nil, cmdError("setmaxbasal", "rate", err)
+	}
+	rate := medtronic.Insulin(1000.0*f + 0.5)
+	log.Printf("setting max basal rate to %v units/hour", rate)
+	pump.SetMaxBasal(rate)
+	return nil, nil
+}
+
+func setMaxBolus(pump *medtronic.Pump, args Arguments) (interface{}, error) {
+	f, err := args.Float("units")
+	if err != nil {
+		return nil, cmdError("setmaxbolus", "units", err)
+	}
+	amount := medtronic.Insulin(1000.0*f + 0.5)
+	log.Printf("setting max bolus to %v units", amount)
+	pump.SetMaxBolus(amount)
+	return nil, nil
+}
+
+func setTempBasal(pump *medtronic.Pump, args Arguments) (interface{}, error) {
+	minutes, err := args.Int("duration")
+	if err != nil {
+		return nil, setTempBasalUsage(err)
+	}
+	duration := time.Duration(minutes) * time.Minute
+	f, err := args.Float("rate")
+	if err != nil {
+		return nil, setTempBasalUsage(err)
+	}
+	temp, err := args.String("temp")
+	if err != nil {
+		return nil, setTempBasalUsage(err)
+	}
+	switch temp {
+	case "absolute":
+		rate := medtronic.Insulin(1000.0*f + 0.5)
+		log.Printf("setting temporary basal of %v units/hour for %d minutes", rate, minutes)
+		pump.SetAbsoluteTempBasal(duration, rate)
+	case "percent":
+		percent := int(f + 0.5)
+		log.Printf("setting temporary basal of %d%% for %d minutes", percent, minutes)
+		pump.SetPercentTempBasal(duration, percent)
+	default:
+		return nil, setTempBasalUsage(fmt.Errorf("unknown temp basal type %q", temp))
+	}
+	return nil, nil
+}
+
+func setTempBasalUsage(err error) error {
+	return cmdError("settempbasal", "temp rate duration", err)
+}
+
+func settings(pump *medtronic.Pump, _ Arguments) (interface{}, error) {
+	return pump.Settings(), nil
+}
+
+func status(pump *medtronic.Pump, _ Arguments) (interface{}, error) {
+	return pump.Status(), nil
+}
+
+func suspend(pump *medtronic.Pump, _ Arguments) (interface{}, error) {
+	log.Printf("suspending pump")
+	pump.Suspend(true)
+	return nil, nil
+}
+
+func targets(pump *medtronic.Pump, _ Arguments) (interface{}, error) {
+	return pump.GlucoseTargets(), nil
+}
+
+func tempBasal(pump *medtronic.Pump, _ Arguments) (interface{}, error) {
+	return pump.TempBasal(), nil
+}
+
+func wakeup(pump *medtronic.Pump, _ Arguments) (interface{}, error) {
+	// pump.Wakeup has already been called
+	return nil, nil
+}
+
+func wizard(pump *medtronic.Pump, args Arguments) (interface{}, error) {
+	bg, err := args.Float("bg")
+	if err != nil {
+		return nil, wizardUsage(err)
+	}
+	carbs, err := args.Float("carbs")
+	if err != nil {
+		return nil, wizardUsage(err)
+	}
+	g := medtronic.Glucose(bg + 0.5)
+	if pump.GlucoseUnits() == medtronic.MMolPerLiter {
+		g = medtronic.Glucose(1000.0*bg + 0.5)
+	}
+	c := medtronic.Carbs(carbs + 0.5)
+	if pump.CarbUnits() == medtronic.Exchanges {
+		c = medtronic.Carbs(10.0*carbs + 0.5)
+	}
+	if pump.Error() != nil {
+		return nil, nil
+	}
+	return pump.BolusWizard(g, c, time.Now(), 0), nil
+}
+
+func wizardUsage(err error) error {
+	return cmdError("wizard", "bg carbs (bg 0 if none)", err)
+}
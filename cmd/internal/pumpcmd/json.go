@@ -1,8 +1,9 @@
-package main
+package pumpcmd
 
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"strings"
 	"time"
@@ -10,22 +11,36 @@ import (
 	"github.com/thecubic/medtronic"
 )
 
-func showInternal(v interface{}) {
-	fmt.Printf("%+v\n", v)
+type (
+	// Printer represents a function that prints an arbitrary value.
+	Printer func(io.Writer, interface{})
+)
+
+var (
+	// Formats maps the name of each output format to its Printer.
+	Formats = map[string]Printer{
+		"internal": showInternal,
+		"json":     showJSON,
+		"openaps":  showOpenAPS,
+	}
+)
+
+func showInternal(w io.Writer, v interface{}) {
+	fmt.Fprintf(w, "%+v\n", v)
 }
 
-func showJSON(v interface{}) {
+func showJSON(w io.Writer, v interface{}) {
 	b, err := json.MarshalIndent(v, "", "  ")
 	if err != nil {
-		fmt.Println(err)
-		fmt.Println(v)
+		fmt.Fprintln(w, err)
+		fmt.Fprintln(w, v)
 		return
 	}
-	fmt.Println(string(b))
+	fmt.Fprintln(w, string(b))
 }
 
-func showOpenAPS(v interface{}) {
-	showJSON(OpenAPSJSON(v))
+func showOpenAPS(w io.Writer, v interface{}) {
+	showJSON(w, OpenAPSJSON(v))
 }
 
 // OpenAPSJSON converts v into a value that the json package
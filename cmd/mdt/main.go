@@ -7,26 +7,15 @@ import (
 	"log"
 	"os"
 	"sort"
-	"strconv"
 	"strings"
 
 	"github.com/thecubic/medtronic"
-)
-
-type (
-	// Printer represents a function that prints an arbitrary value.
-	Printer func(interface{})
+	"github.com/thecubic/medtronic/cmd/internal/pumpcmd"
 )
 
 var (
 	formatFlag = flag.String("f", "openaps", "print result in specified `format`")
 
-	format = map[string]Printer{
-		"internal": showInternal,
-		"json":     showJSON,
-		"openaps":  showOpenAPS,
-	}
-
 	openAPSMode bool
 )
 
@@ -35,13 +24,13 @@ func usage() {
 	eprintf("   or: %s [options] command [ args.json ]\n", os.Args[0])
 	flag.PrintDefaults()
 	fmts := ""
-	for k := range format {
+	for k := range pumpcmd.Formats {
 		fmts += " " + k
 	}
 	eprintf("output formats:%s\n", fmts)
-	keys := make([]string, len(command))
+	keys := make([]string, len(pumpcmd.Table))
 	i := 0
-	for k := range command {
+	for k := range pumpcmd.Table {
 		keys[i] = k
 		i++
 	}
@@ -57,7 +46,7 @@ func usage() {
 func main() {
 	flag.Usage = usage
 	flag.Parse()
-	printFn := format[*formatFlag]
+	printFn := pumpcmd.Formats[*formatFlag]
 	if printFn == nil {
 		eprintf("%s: unknown format\n", *formatFlag)
 		usage()
@@ -67,7 +56,7 @@ func main() {
 		usage()
 	}
 	name := flag.Arg(0)
-	cmd, found := command[name]
+	cmd, found := pumpcmd.Table[name]
 	if !found {
 		eprintf("%s: unknown command\n", name)
 		usage()
@@ -78,12 +67,21 @@ func main() {
 	defer pump.Close()
 	pump.Wakeup()
 	exitOnError(pump)
-	result := cmd.Cmd(pump, args)
+	result, err := cmd.Cmd(pump, args)
+	if err != nil {
+		e, ok := err.(pumpcmd.UsageError)
+		if ok {
+			eprintf("%v\n", e)
+			eprintf("usage: %s %s\n", e.Name, e.Usage)
+			os.Exit(1)
+		}
+		log.Fatal(err)
+	}
 	exitOnError(pump)
 	if result == nil {
 		return
 	}
-	printFn(result)
+	printFn(os.Stdout, result)
 }
 
 func exitOnError(pump *medtronic.Pump) {
@@ -98,85 +96,20 @@ func exitOnError(pump *medtronic.Pump) {
 	log.Fatal(err)
 }
 
-type (
-	// Arguments represents the formal and actual parameters for a command.
-	Arguments map[string]interface{}
-)
-
-// String returns the string value associated with the given key.
-func (args Arguments) String(key string) (string, error) {
-	arg := args[key]
-	s, ok := arg.(string)
-	if !ok {
-		return s, fmt.Errorf("%q argument must be a string", key)
-	}
-	return s, nil
-}
-
-// Float returns the float64 value associated with the given key.
-func (args Arguments) Float(key string) (float64, error) {
-	arg := args[key]
-	if openAPSMode {
-		f, ok := arg.(float64)
-		if !ok {
-			return f, fmt.Errorf("%q parameter must be a number", key)
-		}
-		return f, nil
-	}
-	return strconv.ParseFloat(arg.(string), 64)
-}
-
-// Int returns the int value associated with the given key.
-func (args Arguments) Int(key string) (int, error) {
-	arg := args[key]
-	if openAPSMode {
-		f, ok := arg.(float64)
-		if !ok {
-			return int(f), fmt.Errorf("%q argument must be a number", key)
-		}
-		return int(f), nil
-	}
-	return strconv.Atoi(arg.(string))
-}
-
-// Strings returns the []string value associated with the given key.
-func (args Arguments) Strings(key string) ([]string, error) {
-	arg := args[key]
-	if openAPSMode {
-		v, ok := arg.([]interface{})
-		if !ok {
-			return nil, fmt.Errorf("%q argument must be an array", key)
-		}
-		a := make([]string, len(v))
-		for i, si := range v {
-			s, ok := si.(string)
-			if !ok {
-				return nil, fmt.Errorf("%q argument must be a list of strings", key)
-			}
-			a[i] = s
-		}
-		return a, nil
-	}
-	return arg.([]string), nil
-}
-
-func getArgs(name string, cmd Command) Arguments {
-	params := cmd.Params
+func getArgs(name string, cmd pumpcmd.Command) pumpcmd.Arguments {
 	argv := flag.Args()[1:]
-	if len(params) == 0 {
-		if len(argv) != 0 {
-			log.Fatalf("%s does not take any arguments", name)
-		}
-		return nil
+	if openAPSMode && len(cmd.Params) != 0 {
+		return openAPSArgs(name, cmd, argv)
 	}
-	if openAPSMode {
-		return openAPSArgs(name, params, argv, cmd.Variadic)
+	args, err := pumpcmd.BindArgs(name, cmd, argv)
+	if err != nil {
+		log.Fatal(err)
 	}
-	return cliArgs(name, params, argv, cmd.Variadic)
+	return args
 }
 
 // Parse an openaps JSON file for arguments.
-func openAPSArgs(name string, params []string, argv []string, variadic bool) Arguments {
+func openAPSArgs(name string, cmd pumpcmd.Command, argv []string) pumpcmd.Arguments {
 	if len(argv) != 1 || !strings.HasSuffix(argv[0], ".json") {
 		log.Fatalf("%s: openaps format requires single JSON argument file", name)
 	}
@@ -186,44 +119,16 @@ func openAPSArgs(name string, params []string, argv []string, variadic bool) Arg
 	if err != nil {
 		log.Fatalf("%s: %v", name, err)
 	}
-	args := make(Arguments)
+	args := make(pumpcmd.Arguments)
 	err = json.NewDecoder(f).Decode(&args)
 	if err != nil {
 		log.Fatalf("%s: %v", name, err)
 	}
 	_ = f.Close()
 	// Check that all parameters are present.
-	for _, k := range params {
-		_, present := args[k]
-		if !present {
-			log.Fatalf("%s: argument file %s is missing %q parameter", name, file, k)
-		}
-	}
-	return args
-}
-
-// Collect command-line arguments.
-func cliArgs(name string, params []string, argv []string, variadic bool) Arguments {
-	if !variadic && len(argv) != len(params) {
-		var p string
-		if len(params) != 1 {
-			p = "s"
-		}
-		log.Fatalf("%s requires %d argument%s", name, len(params), p)
-	}
-	args := make(Arguments)
-	for i, k := range params {
-		if variadic && i == len(params)-1 {
-			// Bind all remaining args to this parameter.
-			args[k] = argv[i:]
-			break
-		}
-		if i >= len(argv) {
-			// Bind remaining parameters to "".
-			args[k] = ""
-			continue
-		}
-		args[k] = argv[i]
+	err = pumpcmd.CheckArgs(name, cmd, args)
+	if err != nil {
+		log.Fatalf("argument file %s: %v", file, err)
 	}
 	return args
 }
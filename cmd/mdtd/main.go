@@ -0,0 +1,339 @@
+// The mdtd program keeps a pump open and awake and serves the mdt commands
+// as an HTTP/JSON API, so that several clients can share the radio.
+//
+// Each command is available at /name.  Commands that change the pump's state
+// must use POST with Content-Type application/json, and their arguments
+// are given only as a JSON object in the request body.  Other commands take their
+// arguments from the request body or from URL query parameters
+// (repeated for a variadic parameter).
+// To keep web pages from sending commands through a user's browser,
+// state-changing requests with an Origin header for a different host are rejected,
+// and if the -t flag is given they must include the token
+// in an "Authorization: Bearer" header.
+// The format query parameter selects the output format.
+// Safety limits given by the -max and -dup flags are enforced for state-changing commands,
+// which are rejected with status 403 if they would exceed them.
+// When limits are set, the execute command only accepts opcodes known to be queries.
+// A GET request for / lists the commands and their parameters.
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/thecubic/medtronic"
+	"github.com/thecubic/medtronic/cmd/internal/pumpcmd"
+)
+
+var (
+	addrFlag      = flag.String("a", "localhost:8000", "listen on `address`")
+	formatFlag    = flag.String("f", "openaps", "default result `format`")
+	keepAliveFlag = flag.Duration("k", time.Minute, "contact the pump at this `interval` to keep it awake")
+	tokenFlag     = flag.String("t", "", "require this bearer `token` for state-changing commands")
+
+	// Safety limits for state-changing commands (0 for no limit).
+	maxBolusFlag  = flag.Float64("maxbolus", 0, "reject boluses larger than this many `units`")
+	maxBasalFlag  = flag.Float64("maxbasal", 0, "reject basal and temp basal rates above this `rate`")
+	maxTempFlag   = flag.Float64("maxtemp", 0, "reject temp basals above this `multiple` of the scheduled rate")
+	maxIOBFlag    = flag.Float64("maxiob", 0, "reject boluses and temp basals that would raise insulin on board above this many `units`")
+	duplicateFlag = flag.Duration("dup", 0, "reject a bolus within this `interval` after another bolus")
+	buttonFlag    = flag.Bool("allowbutton", false, "allow button presses when limits are set")
+	setClockFlag  = flag.Bool("allowsetclock", false, "allow setting the clock when limits are set")
+
+	// Commands that change the pump's state.
+	stateChanging = map[string]bool{
+		"bolus":        true,
+		"button":       true,
+		"execute":      true,
+		"resume":       true,
+		"setclock":     true,
+		"setmaxbasal":  true,
+		"setmaxbolus":  true,
+		"settempbasal": true,
+		"suspend":      true,
+	}
+
+	priority = map[string]medtronic.Priority{
+		"bolus":        medtronic.HighPriority,
+		"resume":       medtronic.HighPriority,
+		"settempbasal": medtronic.HighPriority,
+		"suspend":      medtronic.HighPriority,
+		"cgm":          medtronic.LowPriority,
+		"delivery":     medtronic.LowPriority,
+		"history":      medtronic.LowPriority,
+		"iob":          medtronic.LowPriority,
+		"wizard":       medtronic.LowPriority,
+	}
+)
+
+type server struct {
+	session *medtronic.Session
+
+	// Only accessed by session requests, which are serialized.
+	lastContact time.Time
+}
+
+func main() {
+	flag.Parse()
+	if pumpcmd.Formats[*formatFlag] == nil {
+		log.Fatalf("%s: unknown format", *formatFlag)
+	}
+	pump := medtronic.Open()
+	if pump.Error() != nil {
+		log.Fatal(pump.Error())
+	}
+	limits := medtronic.Limits{
+		MaxBolus:             insulinFlag(*maxBolusFlag),
+		MaxBasal:             insulinFlag(*maxBasalFlag),
+		MaxTempBasalMultiple: *maxTempFlag,
+		MaxIOB:               insulinFlag(*maxIOBFlag),
+		DuplicateBolusWindow: *duplicateFlag,
+		AllowButtons:         *buttonFlag,
+		AllowSetClock:        *setClockFlag,
+	}
+	if limits != (medtronic.Limits{}) {
+		pump.SetGuard(limits)
+	}
+	s := &server{session: medtronic.NewSession(pump)}
+	defer s.session.Close()
+	go s.keepAlive()
+	http.HandleFunc("/", s.handle)
+	log.Printf("listening on %s", *addrFlag)
+	log.Fatal(http.ListenAndServe(*addrFlag, nil))
+}
+
+func insulinFlag(units float64) medtronic.Insulin {
+	return medtronic.Insulin(1000*units + 0.5)
+}
+
+// wakeup wakes the pump if it has not been contacted recently.
+func (s *server) wakeup(pump *medtronic.Pump) {
+	if time.Since(s.lastContact) < *keepAliveFlag {
+		return
+	}
+	pump.Wakeup()
+	if pump.Error() == nil {
+		s.lastContact = time.Now()
+	}
+}
+
+func (s *server) keepAlive() {
+	for {
+		err := s.session.Do(medtronic.LowPriority, s.wakeup)
+		if err == medtronic.ErrSessionClosed {
+			return
+		}
+		if err != nil {
+			log.Print(err)
+		}
+		time.Sleep(*keepAliveFlag / 2)
+	}
+}
+
+func (s *server) handle(w http.ResponseWriter, r *http.Request) {
+	log.Printf("%s %s", r.Method, r.URL)
+	name := strings.Trim(r.URL.Path, "/")
+	if name == "" {
+		listCommands(w)
+		return
+	}
+	cmd, found := pumpcmd.Table[name]
+	if !found {
+		writeError(w, http.StatusNotFound, fmt.Errorf("%s: unknown command", name))
+		return
+	}
+	if stateChanging[name] {
+		status, err := checkStateChanging(name, r)
+		if err != nil {
+			writeError(w, status, err)
+			return
+		}
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = *formatFlag
+	}
+	printFn := pumpcmd.Formats[format]
+	if printFn == nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("%s: unknown format", format))
+		return
+	}
+	args, err := requestArgs(name, cmd, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	pri, found := priority[name]
+	if !found {
+		pri = medtronic.NormalPriority
+	}
+	var result interface{}
+	var cmdErr error
+	err = s.session.Do(pri, func(pump *medtronic.Pump) {
+		s.wakeup(pump)
+		if pump.Error() != nil {
+			return
+		}
+		result, cmdErr = cmd.Cmd(pump, args)
+		if pump.Error() == nil {
+			s.lastContact = time.Now()
+		}
+	})
+	if cmdErr != nil {
+		writeError(w, http.StatusBadRequest, cmdErr)
+		return
+	}
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	if result == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if format == "internal" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	printFn(w, result)
+}
+
+// checkStateChanging verifies that a request for a state-changing command
+// cannot have been made by a web page on another site,
+// and returns the HTTP status and error if not.
+func checkStateChanging(name string, r *http.Request) (int, error) {
+	if r.Method != http.MethodPost {
+		return http.StatusMethodNotAllowed, fmt.Errorf("%s requires POST", name)
+	}
+	ct, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || ct != "application/json" {
+		return http.StatusUnsupportedMediaType, fmt.Errorf("%s requires Content-Type application/json", name)
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		u, err := url.Parse(origin)
+		if err != nil || u.Host != r.Host {
+			return http.StatusForbidden, fmt.Errorf("%s: cross-origin request from %s", name, origin)
+		}
+	}
+	if *tokenFlag != "" {
+		auth := r.Header.Get("Authorization")
+		if subtle.ConstantTimeCompare([]byte(auth), []byte("Bearer "+*tokenFlag)) != 1 {
+			return http.StatusUnauthorized, fmt.Errorf("%s: missing or invalid token", name)
+		}
+	}
+	return 0, nil
+}
+
+// requestArgs returns the arguments from the request body if it is a JSON object,
+// otherwise from the URL query parameters.
+// Arguments for state-changing commands are only taken from the request body.
+func requestArgs(name string, cmd pumpcmd.Command, r *http.Request) (pumpcmd.Arguments, error) {
+	args := make(pumpcmd.Arguments)
+	if r.Method == http.MethodPost && r.ContentLength != 0 {
+		err := json.NewDecoder(r.Body).Decode(&args)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+	} else if !stateChanging[name] {
+		q := r.URL.Query()
+		for i, k := range cmd.Params {
+			v, present := q[k]
+			if !present {
+				continue
+			}
+			if cmd.Variadic && i == len(cmd.Params)-1 {
+				args[k] = v
+			} else {
+				args[k] = v[0]
+			}
+		}
+	}
+	if cmd.Variadic {
+		k := cmd.Params[len(cmd.Params)-1]
+		_, present := args[k]
+		if !present {
+			args[k] = []string{}
+		}
+	}
+	if len(args) == 0 {
+		args = nil
+	}
+	err := pumpcmd.CheckArgs(name, cmd, args)
+	if err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+func errorStatus(err error) int {
+	if err == medtronic.ErrSessionClosed {
+		return http.StatusServiceUnavailable
+	}
+	var noResponse medtronic.NoResponseError
+	if errors.As(err, &noResponse) {
+		return http.StatusGatewayTimeout
+	}
+	var invalid medtronic.InvalidCommandError
+	if errors.As(err, &invalid) {
+		return http.StatusConflict
+	}
+	var rejected medtronic.GuardError
+	if errors.As(err, &rejected) {
+		return http.StatusForbidden
+	}
+	return http.StatusBadGateway
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	log.Print(err)
+	v := map[string]string{"error": err.Error()}
+	e, ok := err.(pumpcmd.UsageError)
+	if ok {
+		v["usage"] = e.Name + " " + e.Usage
+	}
+	writeJSON(w, status, v)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		log.Print(err)
+	}
+}
+
+func listCommands(w http.ResponseWriter) {
+	type commandInfo struct {
+		Name     string   `json:"name"`
+		Params   []string `json:"params"`
+		Variadic bool     `json:"variadic,omitempty"`
+		Post     bool     `json:"post,omitempty"`
+	}
+	var list []commandInfo
+	for name, cmd := range pumpcmd.Table {
+		params := cmd.Params
+		if params == nil {
+			params = []string{}
+		}
+		list = append(list, commandInfo{
+			Name:     name,
+			Params:   params,
+			Variadic: cmd.Variadic,
+			Post:     stateChanging[name],
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	writeJSON(w, http.StatusOK, list)
+}
@@ -10,7 +10,8 @@ import (
 )
 
 var (
-	model = flag.Int("m", 523, "pump model")
+	model    = flag.Int("m", 523, "pump model")
+	annotate = flag.Bool("a", false, "annotate the fields of each record")
 )
 
 func main() {
@@ -23,11 +24,16 @@ func main() {
 			continue
 		}
 		fmt.Printf("[ % X ]\n", data)
-		r, err := medtronic.DecodeHistoryRecord(data, family)
+		r, fields, err := medtronic.AnnotateHistoryRecord(data, family)
 		if err != nil {
 			fmt.Printf("decoding error: %v\n", err)
 			continue
 		}
+		if *annotate {
+			for _, f := range fields {
+				fmt.Printf("%3d  %-12s % X\n", f.Offset, f.Name, r.Data[f.Offset:f.Offset+f.Length])
+			}
+		}
 		b, err := json.MarshalIndent(r, "", "  ")
 		if err != nil {
 			fmt.Printf("marshaling error: %v\n", err)
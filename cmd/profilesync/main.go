@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/thecubic/medtronic"
+)
+
+var (
+	profileName = flag.String("p", "", "use the Nightscout profile with this `name` instead of the default")
+	yesFlag     = flag.Bool("y", false, "apply changes without asking for confirmation")
+	dryRun      = flag.Bool("n", false, "show changes only; do not send to pump")
+)
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [options] (file.json | http://host/api/v1/profile.json)\n", os.Args[0])
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	data, err := readSource(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	ns, err := medtronic.DecodeNightscoutProfile(data, *profileName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	pump := medtronic.Open()
+	defer pump.Close()
+	pump.Wakeup()
+	family := pump.Family()
+	carbUnits := pump.CarbUnits()
+	glucoseUnits := pump.GlucoseUnits()
+	current := pump.Profile()
+	if pump.Error() != nil {
+		log.Fatal(pump.Error())
+	}
+	profile, err := ns.PumpProfile(family, carbUnits, glucoseUnits)
+	if err != nil {
+		log.Fatal(err)
+	}
+	diffs := profile.Diff(current)
+	if len(diffs) == 0 {
+		fmt.Println("pump settings match the Nightscout profile")
+		return
+	}
+	writable := false
+	for _, d := range diffs {
+		showDiff(d)
+		if !d.ReadOnly {
+			writable = true
+		}
+	}
+	if *dryRun || !writable {
+		return
+	}
+	if !*yesFlag && !confirm("apply these changes to the pump?") {
+		return
+	}
+	pump.SetProfile(profile.Changes(current))
+	if pump.Error() != nil {
+		log.Fatal(pump.Error())
+	}
+}
+
+// readSource reads a Nightscout profile document from a file or an HTTP URL.
+func readSource(src string) ([]byte, error) {
+	if !strings.HasPrefix(src, "http://") && !strings.HasPrefix(src, "https://") {
+		return ioutil.ReadFile(src)
+	}
+	resp, err := http.Get(src)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", src, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+func showDiff(d medtronic.ProfileDiff) {
+	if d.ReadOnly {
+		fmt.Printf("%s (must be set on the pump):\n", d.Name)
+	} else {
+		fmt.Printf("%s:\n", d.Name)
+	}
+	for _, e := range d.Current {
+		fmt.Printf("  - %s\n", e)
+	}
+	for _, e := range d.New {
+		fmt.Printf("  + %s\n", e)
+	}
+}
+
+func confirm(prompt string) bool {
+	fmt.Printf("%s [y/N] ", prompt)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/thecubic/medtronic"
+)
+
+var (
+	restoreFlag = flag.Bool("r", false, "restore settings from the file instead of saving them")
+	yesFlag     = flag.Bool("y", false, "restore without asking for confirmation")
+	dryRun      = flag.Bool("n", false, "show the changes a restore would make; do not send to pump")
+)
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [options] file.json\n", os.Args[0])
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	file := flag.Arg(0)
+	pump := medtronic.Open()
+	defer pump.Close()
+	pump.Wakeup()
+	if *restoreFlag || *dryRun {
+		restore(pump, file)
+	} else {
+		backup(pump, file)
+	}
+	if pump.Error() != nil {
+		log.Fatal(pump.Error())
+	}
+}
+
+func backup(pump *medtronic.Pump, file string) {
+	b := pump.Backup()
+	if pump.Error() != nil {
+		return
+	}
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = ioutil.WriteFile(file, append(data, '\n'), 0644)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("saved settings of pump %s (model %s) to %s\n", b.PumpID, b.Model, file)
+}
+
+func restore(pump *medtronic.Pump, file string) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var b medtronic.Backup
+	err = json.Unmarshal(data, &b)
+	if err != nil {
+		log.Fatal(err)
+	}
+	diffs := pump.Restore(b, true)
+	if pump.Error() != nil {
+		return
+	}
+	if len(diffs) == 0 {
+		fmt.Println("pump settings match the backup")
+		return
+	}
+	fmt.Printf("backup of pump %s (model %s) from %s:\n", b.PumpID, b.Model, b.Time.Format("2006-01-02 15:04"))
+	writable := false
+	for _, d := range diffs {
+		showDiff(d)
+		if !d.ReadOnly {
+			writable = true
+		}
+	}
+	if *dryRun || !writable {
+		return
+	}
+	if !*yesFlag && !confirm("restore these settings to the pump?") {
+		return
+	}
+	pump.Restore(b, false)
+}
+
+func showDiff(d medtronic.ProfileDiff) {
+	if d.ReadOnly {
+		fmt.Printf("%s (must be set on the pump):\n", d.Name)
+	} else {
+		fmt.Printf("%s:\n", d.Name)
+	}
+	for _, e := range d.Current {
+		fmt.Printf("  - %s\n", e)
+	}
+	for _, e := range d.New {
+		fmt.Printf("  + %s\n", e)
+	}
+}
+
+func confirm(prompt string) bool {
+	fmt.Printf("%s [y/N] ", prompt)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
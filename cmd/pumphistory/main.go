@@ -37,14 +37,27 @@ func main() {
 	pump := medtronic.Open()
 	defer pump.Close()
 	pump.Wakeup()
-	results := pump.History(cutoff)
 	if *nsFlag {
+		results := certain(pump.CorrectedHistory(cutoff))
 		medtronic.ReverseHistory(results)
-		fmt.Println(nightscout.JSON(medtronic.Treatments(results)))
+		fmt.Println(nightscout.JSON(pump.Treatments(results)))
 	} else {
-		fmt.Println(nightscout.JSON(results))
+		fmt.Println(nightscout.JSON(pump.History(cutoff)))
 	}
 	if pump.Error() != nil {
 		log.Fatal(pump.Error())
 	}
 }
+
+// certain omits records with uncertain times, which should not be uploaded.
+func certain(records medtronic.History, uncertain []bool) medtronic.History {
+	var results medtronic.History
+	for i, r := range records {
+		if uncertain[i] {
+			log.Printf("omitting %v record with uncertain time %s", r.Type(), r.Time.Format(medtronic.UserTimeLayout))
+			continue
+		}
+		results = append(results, r)
+	}
+	return results
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/thecubic/medtronic"
+)
+
+var (
+	jsonFlag = flag.Bool("j", false, "print the report as JSON")
+	numHours = flag.Int("n", 7*24, "search `hours` of pump history for setting changes")
+)
+
+// Report is the result of an audit.
+type Report struct {
+	Differences []medtronic.ProfileDiff
+	Changes     []medtronic.SettingChange
+}
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [options] (declared.json | declared.yaml)\n", os.Args[0])
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	data, err := ioutil.ReadFile(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	decode := medtronic.DecodeDeclaredSettings
+	switch filepath.Ext(flag.Arg(0)) {
+	case ".yaml", ".yml":
+		decode = medtronic.DecodeDeclaredSettingsYAML
+	}
+	declared, err := decode(data)
+	if err != nil {
+		log.Fatal(err)
+	}
+	pump := medtronic.Open()
+	defer pump.Close()
+	pump.Wakeup()
+	current := pump.Backup()
+	if pump.Error() != nil {
+		log.Fatal(pump.Error())
+	}
+	cutoff := time.Now().Add(-time.Duration(*numHours) * time.Hour)
+	records, _ := pump.CorrectedHistory(cutoff)
+	if pump.Error() != nil {
+		log.Fatal(pump.Error())
+	}
+	changes := medtronic.SettingChanges(records)
+	r := Report{Differences: declared.Audit(current)}
+	for _, c := range changes {
+		r.Changes = append(r.Changes, c)
+	}
+	sort.Slice(r.Changes, func(i, j int) bool { return r.Changes[i].Time.After(r.Changes[j].Time) })
+	if *jsonFlag {
+		e := json.NewEncoder(os.Stdout)
+		e.SetIndent("", "  ")
+		err = e.Encode(r)
+		if err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		printReport(r, changes)
+	}
+	if len(r.Differences) != 0 {
+		os.Exit(1)
+	}
+}
+
+func printReport(r Report, changes map[string]medtronic.SettingChange) {
+	if len(r.Differences) == 0 {
+		fmt.Println("pump settings match the declared configuration")
+	}
+	for _, d := range r.Differences {
+		fmt.Printf("%s:", d.Name)
+		if c, found := changes[d.Name]; found {
+			fmt.Printf(" (last changed %s)", c.Time.Local().Format(medtronic.UserTimeLayout))
+		}
+		fmt.Println()
+		for _, e := range d.New {
+			fmt.Printf("  declared: %s\n", e)
+		}
+		for _, e := range d.Current {
+			fmt.Printf("  pump:     %s\n", e)
+		}
+	}
+	if len(r.Changes) == 0 {
+		return
+	}
+	fmt.Printf("\nsetting changes in the last %d hours:\n", *numHours)
+	for _, c := range r.Changes {
+		fmt.Printf("%s %s", c.Time.Local().Format(medtronic.UserTimeLayout), c.Name)
+		if len(c.Before) != 0 {
+			fmt.Printf(" from %v", c.Before)
+		}
+		if len(c.After) != 0 {
+			fmt.Printf(" to %v", c.After)
+		}
+		fmt.Println()
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/thecubic/medtronic/packet"
 )
@@ -120,22 +121,10 @@ const (
 	maxNAKs                 = 10
 )
 
-var (
-	shortPacket = make([]byte, shortPacketLength)
-	longPacket  = make([]byte, longPacketLength)
-	ackPacket   []byte
-)
-
-func precomputePackets() {
-	addr := PumpAddress()
-
-	shortPacket[0] = packet.Pump
-	copy(shortPacket[1:4], addr)
-
-	longPacket[0] = packet.Pump
-	copy(longPacket[1:4], addr)
-
-	ackPacket = shortPumpPacket(ack)
+// setAddress precomputes the packet header and ACK packet for the given pump address.
+func (pump *Pump) setAddress(addr []byte) {
+	pump.header = append([]byte{packet.Pump}, addr...)
+	pump.ackPacket = pump.shortPumpPacket(ack)
 }
 
 // shortPumpPacket constructs a 7-byte packet with the specified command code:
@@ -144,8 +133,9 @@ func precomputePackets() {
 //   command code
 //   length of parameters (0)
 //   CRC-8 (added by packet.Encode)
-func shortPumpPacket(cmd Command) []byte {
-	p := shortPacket
+func (pump *Pump) shortPumpPacket(cmd Command) []byte {
+	p := make([]byte, shortPacketLength)
+	copy(p, pump.header)
 	p[4] = byte(cmd)
 	p[5] = 0
 	return packet.Encode(p)
@@ -159,8 +149,9 @@ func shortPumpPacket(cmd Command) []byte {
 //   length of parameters (or fragment number if non-zero)
 //   64 bytes of parameters plus zero padding
 //   CRC-8 (added by packet.Encode)
-func longPumpPacket(cmd Command, fragNum int, params []byte) []byte {
-	p := longPacket
+func (pump *Pump) longPumpPacket(cmd Command, fragNum int, params []byte) []byte {
+	p := make([]byte, longPacketLength)
+	copy(p, pump.header)
 	p[4] = byte(cmd)
 	if fragNum == 0 {
 		p[5] = byte(len(params))
@@ -169,10 +160,6 @@ func longPumpPacket(cmd Command, fragNum int, params []byte) []byte {
 		p[5] = uint8(fragNum)
 	}
 	copy(p[6:], params)
-	// Zero-pad the remainder of the packet.
-	for i := 6 + len(params); i < longPacketLength; i++ {
-		p[i] = 0
-	}
 	return packet.Encode(p)
 }
 
@@ -181,17 +168,23 @@ func longPumpPacket(cmd Command, fragNum int, params []byte) []byte {
 // followed by an exchange with the actual arguments.
 func (pump *Pump) Execute(cmd Command, params ...byte) []byte {
 	if len(params) == 0 {
-		return pump.perform(cmd, cmd, shortPumpPacket(cmd))
+		return pump.perform(cmd, cmd, pump.shortPumpPacket(cmd))
 	}
-	pump.perform(cmd, ack, shortPumpPacket(cmd))
+	pump.perform(cmd, ack, pump.shortPumpPacket(cmd))
 	if pump.NoResponse() {
-		pump.SetError(fmt.Errorf("%v command not performed", cmd))
+		pump.notPerformed(cmd)
 		return nil
 	}
 	t := pump.Timeout()
 	defer pump.SetTimeout(t)
 	pump.SetTimeout(2 * t)
-	return pump.perform(cmd, ack, longPumpPacket(cmd, 0, params))
+	return pump.perform(cmd, ack, pump.longPumpPacket(cmd, 0, params))
+}
+
+// notPerformed replaces a NoResponseError with one indicating
+// that the command was not performed, wrapping the original error.
+func (pump *Pump) notPerformed(cmd Command) {
+	pump.SetError(fmt.Errorf("%v command not performed: %w", cmd, pump.Error()))
 }
 
 // ExtendedRequest sends a command and a sequence of parameter packets
@@ -208,13 +201,13 @@ func (pump *Pump) ExtendedRequest(cmd Command, params ...byte) []byte {
 			j = len(params)
 		}
 		if seqNum == 1 {
-			pump.perform(cmd, ack, shortPumpPacket(cmd))
+			pump.perform(cmd, ack, pump.shortPumpPacket(cmd))
 			if pump.NoResponse() {
-				pump.SetError(fmt.Errorf("%v command not performed", cmd))
+				pump.notPerformed(cmd)
 				break
 			}
 		}
-		p := longPumpPacket(cmd, seqNum, params[i:j])
+		p := pump.longPumpPacket(cmd, seqNum, params[i:j])
 		data := pump.perform(cmd, ack, p)
 		result = append(result, data...)
 		seqNum++
@@ -224,7 +217,7 @@ func (pump *Pump) ExtendedRequest(cmd Command, params ...byte) []byte {
 		t := pump.Timeout()
 		defer pump.SetTimeout(t)
 		pump.SetTimeout(2 * t)
-		p := longPumpPacket(cmd, seqNum|doneBit, nil)
+		p := pump.longPumpPacket(cmd, seqNum|doneBit, nil)
 		data := pump.perform(cmd, ack, p)
 		result = append(result, data...)
 	}
@@ -255,7 +248,7 @@ func (pump *Pump) ExtendedResponse(cmd Command, params ...byte) []byte {
 			break
 		}
 		// Acknowledge this fragment.
-		data = pump.perform(ack, cmd, ackPacket)
+		data = pump.perform(ack, cmd, pump.ackPacket)
 		expected++
 	}
 	return result
@@ -323,7 +316,7 @@ func (pump *Pump) Download(cmd Command, page int) []byte {
 			return pump.checkPageCRC(ps, page, results)
 		}
 		// Acknowledge the current fragment and receive the next.
-		next := pump.perform(ack, cmd, ackPacket)
+		next := pump.perform(ack, cmd, pump.ackPacket)
 		if pump.Error() != nil {
 			if !pump.NoResponse() {
 				return nil
@@ -377,14 +370,10 @@ func (pump *Pump) checkFragment(ps pageStructure, page int, data []byte, expecte
 func (pump *Pump) handleNoResponse(cmd Command, page int, expected int) []byte {
 	for count := 0; count < maxNAKs; count++ {
 		pump.SetError(nil)
-		data := pump.perform(nak, cmd, shortPumpPacket(nak))
+		data := pump.perform(nak, cmd, pump.shortPumpPacket(nak))
 		if pump.Error() == nil {
 			seqNum := int(data[0] &^ doneBit)
-			format := "history page %d: received fragment %d after %d NAK"
-			if count != 0 {
-				format += "s"
-			}
-			log.Printf(format, page, seqNum, count+1)
+			pump.Logger().Info("received fragment after NAK", "cmd", cmd.String(), "page", page, "fragment", seqNum, "naks", count+1)
 			return data
 		}
 		if !pump.NoResponse() {
@@ -432,24 +421,39 @@ func (pump *Pump) perform(cmd Command, resp Command, p []byte) []byte {
 	}
 	for tries := 0; tries < maxTries; tries++ {
 		pump.SetError(nil)
-		response, rssi := pump.Radio.SendAndReceive(p, pump.Timeout())
+		timeout := pump.checkContext()
+		if pump.Error() != nil {
+			return nil
+		}
+		start := time.Now()
+		response, rssi := pump.Radio.SendAndReceive(p, timeout)
 		if pump.Error() != nil {
+			pump.observe(cmd, resp, tries+1, RadioFailure, start, rssi)
 			continue
 		}
 		if len(response) == 0 {
 			pump.SetError(NoResponseError(cmd))
+			pump.observe(cmd, resp, tries+1, NoResponse, start, rssi)
 			continue
 		}
 		data, err := packet.Decode(response)
 		if err != nil {
 			pump.SetError(err)
+			pump.observe(cmd, resp, tries+1, CRCFailure, start, rssi)
 			continue
 		}
 		if pump.unexpected(cmd, resp, data) {
+			outcome := Unexpected
+			_, rejected := pump.Error().(InvalidCommandError)
+			if rejected {
+				outcome = Rejected
+			}
+			pump.observe(cmd, resp, tries+1, outcome, start, rssi)
 			return nil
 		}
-		logTries(cmd, tries)
+		pump.observe(cmd, resp, tries+1, Success, start, rssi)
 		pump.rssi = rssi
+		pump.logTries(cmd, tries+1)
 		return data[5:]
 	}
 	if pump.Error() == nil {
@@ -458,15 +462,14 @@ func (pump *Pump) perform(cmd Command, resp Command, p []byte) []byte {
 	return nil
 }
 
-func logTries(cmd Command, tries int) {
-	if tries == 0 {
-		return
-	}
-	r := "retries"
+// logTries logs the number of attempts needed for a command and the RSSI of the response.
+// Commands that required retries are logged at Info level, the rest at Debug level.
+func (pump *Pump) logTries(cmd Command, tries int) {
 	if tries == 1 {
-		r = "retry"
+		pump.Logger().Debug("command performed", "cmd", cmd.String(), "tries", tries, "rssi", pump.rssi)
+		return
 	}
-	log.Printf("%v command required %d %s", cmd, tries, r)
+	pump.Logger().Info("command required retries", "cmd", cmd.String(), "tries", tries, "rssi", pump.rssi)
 }
 
 func (pump *Pump) unexpected(cmd Command, resp Command, data []byte) bool {
@@ -474,7 +477,7 @@ func (pump *Pump) unexpected(cmd Command, resp Command, data []byte) bool {
 		pump.BadResponse(cmd, data)
 		return true
 	}
-	if !bytes.Equal(data[:4], shortPacket[:4]) {
+	if !bytes.Equal(data[:4], pump.header) {
 		pump.BadResponse(cmd, data)
 		return true
 	}
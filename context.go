@@ -0,0 +1,87 @@
+package medtronic
+
+import (
+	"context"
+	"time"
+)
+
+// Context returns the context governing pump communications.
+func (pump *Pump) Context() context.Context {
+	if pump.ctx == nil {
+		return context.Background()
+	}
+	return pump.ctx
+}
+
+// SetContext sets the context governing pump communications.
+// Once the context is canceled or its deadline passes,
+// commands stop between packets and the pump's error state
+// is set to the context's error.
+func (pump *Pump) SetContext(ctx context.Context) {
+	pump.ctx = ctx
+}
+
+// withContext sets the pump's context and returns a function
+// that restores the previous one, for use with defer.
+func (pump *Pump) withContext(ctx context.Context) func() {
+	prev := pump.Context()
+	pump.SetContext(ctx)
+	return func() { pump.SetContext(prev) }
+}
+
+// checkContext sets the pump's error state if its context is done
+// and returns the timeout to use for the next packet,
+// limited by the context's deadline.
+func (pump *Pump) checkContext() time.Duration {
+	ctx := pump.Context()
+	err := ctx.Err()
+	if err != nil {
+		pump.SetError(err)
+		return 0
+	}
+	t := pump.Timeout()
+	deadline, ok := ctx.Deadline()
+	if ok {
+		d := time.Until(deadline)
+		if d <= 0 {
+			pump.SetError(context.DeadlineExceeded)
+			return 0
+		}
+		if d < t {
+			t = d
+		}
+	}
+	return t
+}
+
+// ExecuteContext is like Execute but stops when ctx is done.
+func (pump *Pump) ExecuteContext(ctx context.Context, cmd Command, params ...byte) []byte {
+	defer pump.withContext(ctx)()
+	return pump.Execute(cmd, params...)
+}
+
+// ExtendedResponseContext is like ExtendedResponse but stops when ctx is done.
+func (pump *Pump) ExtendedResponseContext(ctx context.Context, cmd Command, params ...byte) []byte {
+	defer pump.withContext(ctx)()
+	return pump.ExtendedResponse(cmd, params...)
+}
+
+// DownloadContext is like Download but stops when ctx is done.
+func (pump *Pump) DownloadContext(ctx context.Context, cmd Command, page int) []byte {
+	defer pump.withContext(ctx)()
+	return pump.Download(cmd, page)
+}
+
+// HistoryContext is like History but stops when ctx is done.
+// The records retrieved before that point are returned.
+func (pump *Pump) HistoryContext(ctx context.Context, since time.Time) History {
+	defer pump.withContext(ctx)()
+	return pump.History(since)
+}
+
+// CGMHistoryContext is like CGMHistory but stops when ctx is done.
+// The records retrieved before that point are returned.
+func (pump *Pump) CGMHistoryContext(ctx context.Context, since time.Time) CGMHistory {
+	defer pump.withContext(ctx)()
+	return pump.CGMHistory(since)
+}
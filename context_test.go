@@ -0,0 +1,64 @@
+package medtronic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ecc1/radio"
+	"github.com/thecubic/medtronic/simulator"
+)
+
+// cancelingRadio cancels a context after a given number of exchanges.
+type cancelingRadio struct {
+	radio.Interface
+	n      int
+	cancel context.CancelFunc
+}
+
+func (r *cancelingRadio) SendAndReceive(data []byte, timeout time.Duration) ([]byte, int) {
+	r.n--
+	if r.n == 0 {
+		r.cancel()
+	}
+	return r.Interface.SendAndReceive(data, timeout)
+}
+
+func TestExecuteContext(t *testing.T) {
+	pump := simulatedPump(t, simulator.DefaultConfig(testPumpID, "523"))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	pump.ExecuteContext(ctx, model)
+	if pump.Error() != context.Canceled {
+		t.Errorf("ExecuteContext with canceled context returned %v, want %v", pump.Error(), context.Canceled)
+	}
+	pump.SetError(nil)
+	if pump.Model() != "523" || pump.Error() != nil {
+		t.Errorf("Model() after ExecuteContext failed: %v", pump.Error())
+	}
+}
+
+func TestDownloadContext(t *testing.T) {
+	pump := simulatedPump(t, simulator.DefaultConfig(testPumpID, "523"))
+	pump.Bolus(1000)
+	if pump.Error() != nil {
+		t.Fatal(pump.Error())
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	// Cancel partway through the page's fragments.
+	pump.Radio = &cancelingRadio{Interface: pump.Radio, n: 5, cancel: cancel}
+	data := pump.DownloadContext(ctx, historyPage, 0)
+	if data != nil || pump.Error() != context.Canceled {
+		t.Errorf("DownloadContext returned %d bytes and %v, want %v", len(data), pump.Error(), context.Canceled)
+	}
+}
+
+func TestHistoryContextDeadline(t *testing.T) {
+	pump := simulatedPump(t, simulator.DefaultConfig(testPumpID, "523"))
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	records := pump.HistoryContext(ctx, time.Time{})
+	if len(records) != 0 || pump.Error() != context.DeadlineExceeded {
+		t.Errorf("HistoryContext returned %d records and %v, want %v", len(records), pump.Error(), context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,334 @@
+package medtronic
+
+import (
+	"fmt"
+	"time"
+)
+
+// GuardRequest describes a state-changing command to be checked by a Guard.
+// Only the fields relevant to the command are set.
+type GuardRequest struct {
+	Command  Command
+	Amount   Insulin           // bolus amount or absolute temp basal rate (rounded as the pump will), or maximum setting
+	Percent  int               // percent temp basal rate
+	Duration time.Duration     // temp basal duration
+	Schedule BasalRateSchedule // basal rate schedule or pattern
+	Suspend  bool              // true to suspend, false to resume
+	Raw      bool              // sent by ExecuteRaw, with unchecked parameters
+}
+
+func (r GuardRequest) String() string {
+	if r.Raw {
+		return "raw " + r.Command.String()
+	}
+	switch r.Command {
+	case bolus, setMaxBolus, setMaxBasal:
+		return fmt.Sprintf("%v %v", r.Command, r.Amount)
+	case setAbsoluteTempBasal:
+		return fmt.Sprintf("%v %v for %v", r.Command, r.Amount, r.Duration)
+	case setPercentTempBasal:
+		return fmt.Sprintf("%v %d%% for %v", r.Command, r.Percent, r.Duration)
+	case setBasalRates, setBasalPatternA, setBasalPatternB:
+		return fmt.Sprintf("%v %v", r.Command, r.Schedule.entries())
+	case suspend:
+		if r.Suspend {
+			return "suspend"
+		}
+		return "resume"
+	default:
+		return r.Command.String()
+	}
+}
+
+// A Guard checks state-changing commands before they are sent to the pump.
+// Check may query the pump, and returns a non-nil error to reject the command.
+type Guard interface {
+	Check(pump *Pump, req GuardRequest) error
+}
+
+// GuardError indicates that a command was rejected by the pump's Guard.
+type GuardError struct {
+	Request GuardRequest
+	Reason  string
+}
+
+func (e GuardError) Error() string {
+	return fmt.Sprintf("%v rejected: %s", e.Request, e.Reason)
+}
+
+// Guard returns the pump's guard, or nil if none has been set.
+func (pump *Pump) Guard() Guard {
+	return pump.guard
+}
+
+// SetGuard sets the guard that checks the pump's state-changing commands.
+// A nil value disables checking.
+func (pump *Pump) SetGuard(g Guard) {
+	pump.guard = g
+}
+
+// allow checks a state-changing command with the pump's guard, if any,
+// and logs the decision.  If the command is rejected, it sets the pump's error
+// to a GuardError and returns false.  If the guard could not query the pump,
+// the pump's error is left unchanged and allow returns false.
+func (pump *Pump) allow(req GuardRequest) bool {
+	if pump.Error() != nil {
+		return false
+	}
+	if pump.guard == nil {
+		return true
+	}
+	err := pump.guard.Check(pump, req)
+	if pump.Error() != nil {
+		pump.Logger().Warn("command not checked", "cmd", req.Command, "request", req.String(), "error", pump.Error())
+		return false
+	}
+	if err != nil {
+		if _, ok := err.(GuardError); !ok {
+			err = GuardError{Request: req, Reason: err.Error()}
+		}
+		pump.reject(err.(GuardError))
+		return false
+	}
+	pump.Logger().Info("command allowed", "cmd", req.Command, "request", req.String())
+	return true
+}
+
+// reject logs a rejected command and sets the pump's error.
+func (pump *Pump) reject(err GuardError) {
+	pump.Logger().Warn("command rejected", "cmd", err.Request.Command, "request", err.Request.String(), "reason", err.Reason)
+	pump.SetError(err)
+}
+
+// queries lists the commands that are known not to change the pump's state.
+var queries = map[Command]bool{
+	wakeup:               true,
+	clock:                true,
+	pumpID:               true,
+	battery:              true,
+	reservoir:            true,
+	firmwareVersion:      true,
+	errorStatus:          true,
+	historyPage:          true,
+	carbUnits:            true,
+	glucoseUnits:         true,
+	carbRatios:           true,
+	insulinSensitivities: true,
+	glucoseTargets512:    true,
+	model:                true,
+	settings512:          true,
+	basalRates:           true,
+	basalPatternA:        true,
+	basalPatternB:        true,
+	tempBasal:            true,
+	glucosePage:          true,
+	isigPage:             true,
+	calibrationFactor:    true,
+	historyPageCount:     true,
+	glucoseTargets:       true,
+	settings:             true,
+	cgmPageCount:         true,
+	status:               true,
+	vcntrPage:            true,
+}
+
+// ExecuteRaw sends a command and parameters to the pump, as Execute does,
+// for commands given by opcode (as by the execute command of mdt and mdtd).
+// A guard cannot interpret the parameters of a raw command,
+// so if the pump has one, only commands known to be queries are sent;
+// others are rejected with a GuardError.
+func (pump *Pump) ExecuteRaw(cmd Command, params ...byte) []byte {
+	if pump.Error() != nil {
+		return nil
+	}
+	if pump.guard != nil && !queries[cmd] {
+		pump.reject(GuardError{Request: GuardRequest{Command: cmd, Raw: true}, Reason: "raw command cannot be checked"})
+		return nil
+	}
+	return pump.Execute(cmd, params...)
+}
+
+// Limits is a Guard that enforces per-user safety limits,
+// in addition to the pump's own settings.
+// Zero values disable the corresponding checks.
+// Boluses, temp basals, and resuming also require the pump's status to be normal,
+// with no bolus in progress; boluses and temp basals are rejected while the pump is suspended.
+// Button presses, setting the clock, and selecting a basal pattern cannot be limited,
+// so they are rejected unless explicitly allowed.
+type Limits struct {
+	// MaxBolus limits boluses and the pump's max bolus setting.
+	MaxBolus Insulin
+	// MaxBasal limits basal rate schedules, absolute temp basal rates,
+	// and the pump's max basal setting.
+	MaxBasal Insulin
+	// MaxTempBasalMultiple limits temp basal rates
+	// relative to the currently scheduled basal rate.
+	MaxTempBasalMultiple float64
+	// MaxIOB limits the insulin on board after a bolus or a temp basal
+	// above the scheduled rate, computed with IOBCurve.
+	MaxIOB   Insulin
+	IOBCurve InsulinCurve
+	// DuplicateBolusWindow is the period after a bolus during which
+	// another bolus is rejected as a duplicate, whatever its amount.
+	DuplicateBolusWindow time.Duration
+	// AllowButtons allows button presses, which can be used
+	// to program a bolus or temp basal through the pump's menus.
+	AllowButtons bool
+	// AllowSetClock allows setting the pump's clock,
+	// which shifts the basal schedule and the times in the history.
+	AllowSetClock bool
+	// AllowSelectBasalPattern allows changing the selected basal pattern.
+	AllowSelectBasalPattern bool
+}
+
+// Check implements the Guard interface.
+func (l Limits) Check(pump *Pump, req GuardRequest) error {
+	reject := func(format string, args ...interface{}) error {
+		return GuardError{Request: req, Reason: fmt.Sprintf(format, args...)}
+	}
+	switch req.Command {
+	case bolus:
+		if l.MaxBolus != 0 && req.Amount > l.MaxBolus {
+			return reject("amount exceeds limit of %v", l.MaxBolus)
+		}
+		if err := l.checkStatus(pump, req, false); err != nil {
+			return err
+		}
+		if err := l.checkDuplicateBolus(pump, req); err != nil {
+			return err
+		}
+		return l.checkIOB(pump, req, req.Amount)
+	case setAbsoluteTempBasal, setPercentTempBasal:
+		if l.MaxBasal != 0 && req.Command == setAbsoluteTempBasal && req.Amount > l.MaxBasal {
+			return reject("rate exceeds limit of %v", l.MaxBasal)
+		}
+		if err := l.checkStatus(pump, req, false); err != nil {
+			return err
+		}
+		return l.checkTempBasal(pump, req)
+	case setBasalRates, setBasalPatternA, setBasalPatternB:
+		for _, r := range req.Schedule {
+			if l.MaxBasal != 0 && r.Rate > l.MaxBasal {
+				return reject("rate at %v exceeds limit of %v", r.Start, l.MaxBasal)
+			}
+		}
+	case setMaxBasal:
+		if l.MaxBasal != 0 && req.Amount > l.MaxBasal {
+			return reject("setting exceeds limit of %v", l.MaxBasal)
+		}
+	case setMaxBolus:
+		if l.MaxBolus != 0 && req.Amount > l.MaxBolus {
+			return reject("setting exceeds limit of %v", l.MaxBolus)
+		}
+	case suspend:
+		if !req.Suspend {
+			return l.checkStatus(pump, req, true)
+		}
+	case button:
+		if !l.AllowButtons {
+			return reject("button presses are not allowed")
+		}
+	case setClock:
+		if !l.AllowSetClock {
+			return reject("setting the clock is not allowed")
+		}
+	case selectBasalPattern:
+		if !l.AllowSelectBasalPattern {
+			return reject("selecting a basal pattern is not allowed")
+		}
+	}
+	return nil
+}
+
+// checkStatus verifies that the pump is operating normally with no bolus in progress,
+// and that it is not suspended unless allowSuspended is true.
+func (l Limits) checkStatus(pump *Pump, req GuardRequest, allowSuspended bool) error {
+	s := pump.Status()
+	if pump.Error() != nil {
+		return pump.Error()
+	}
+	var reason string
+	switch {
+	case !s.Normal():
+		reason = fmt.Sprintf("pump status code is %d", s.Code)
+	case s.Bolusing:
+		reason = "bolus in progress"
+	case s.Suspended && !allowSuspended:
+		reason = "pump is suspended"
+	default:
+		return nil
+	}
+	return GuardError{Request: req, Reason: reason}
+}
+
+// checkDuplicateBolus rejects a bolus if there is any bolus in the recent history.
+// The amount is not compared, since a retried request may differ slightly
+// (or be rounded differently) from the one already delivered.
+func (l Limits) checkDuplicateBolus(pump *Pump, req GuardRequest) error {
+	if l.DuplicateBolusWindow == 0 {
+		return nil
+	}
+	t := pump.Clock()
+	if pump.Error() != nil {
+		return pump.Error()
+	}
+	records := pump.History(t.Add(-l.DuplicateBolusWindow))
+	if pump.Error() != nil {
+		return pump.Error()
+	}
+	for _, r := range records {
+		if r.Type() != Bolus {
+			continue
+		}
+		return GuardError{Request: req, Reason: fmt.Sprintf("previous bolus delivered at %s", r.Time.Format(UserTimeLayout))}
+	}
+	return nil
+}
+
+// checkIOB rejects a command that would raise the insulin on board above the limit.
+func (l Limits) checkIOB(pump *Pump, req GuardRequest, added Insulin) error {
+	if l.MaxIOB == 0 || added <= 0 {
+		return nil
+	}
+	iob := pump.InsulinOnBoard(l.IOBCurve)
+	if pump.Error() != nil {
+		return pump.Error()
+	}
+	if iob.Total()+added > l.MaxIOB {
+		return GuardError{Request: req, Reason: fmt.Sprintf("insulin on board (%v) would exceed limit of %v", iob.Total()+added, l.MaxIOB)}
+	}
+	return nil
+}
+
+// checkTempBasal rejects a temp basal that exceeds the limit relative to the scheduled rate,
+// or that would raise the insulin on board above the limit.
+func (l Limits) checkTempBasal(pump *Pump, req GuardRequest) error {
+	if l.MaxTempBasalMultiple == 0 && l.MaxIOB == 0 {
+		return nil
+	}
+	if req.Command == setPercentTempBasal {
+		// SetPercentTempBasal allows at most 100%, so these never add to the insulin on board.
+		if l.MaxTempBasalMultiple != 0 && float64(req.Percent)/100 > l.MaxTempBasalMultiple {
+			return GuardError{Request: req, Reason: fmt.Sprintf("rate exceeds %g times the scheduled rate", l.MaxTempBasalMultiple)}
+		}
+		return nil
+	}
+	settings := pump.Settings()
+	if pump.Error() != nil {
+		return pump.Error()
+	}
+	basal := pump.selectedBasalRates(settings.SelectedPattern)
+	if pump.Error() != nil {
+		return pump.Error()
+	}
+	t := pump.Clock()
+	if pump.Error() != nil {
+		return pump.Error()
+	}
+	scheduled := basal.BasalRateAt(t).Rate
+	if l.MaxTempBasalMultiple != 0 && float64(req.Amount) > l.MaxTempBasalMultiple*float64(scheduled) {
+		return GuardError{Request: req, Reason: fmt.Sprintf("rate exceeds %g times the scheduled rate of %v", l.MaxTempBasalMultiple, scheduled)}
+	}
+	extra := Insulin(float64(req.Amount-scheduled) * req.Duration.Hours())
+	return l.checkIOB(pump, req, extra)
+}
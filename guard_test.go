@@ -0,0 +1,116 @@
+package medtronic
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/thecubic/medtronic/simulator"
+)
+
+func TestLimits(t *testing.T) {
+	cases := []struct {
+		name      string
+		suspended bool
+		limits    Limits
+		action    func(*Pump)
+		rejected  bool
+	}{
+		{"bolus", false, Limits{MaxBolus: 2000}, func(p *Pump) { p.Bolus(2000) }, false},
+		{"bolus_over_limit", false, Limits{MaxBolus: 2000}, func(p *Pump) { p.Bolus(2500) }, true},
+		{"bolus_while_suspended", true, Limits{}, func(p *Pump) { p.Bolus(1000) }, true},
+		{"resume", true, Limits{}, func(p *Pump) { p.Suspend(false) }, false},
+		{"rounded_bolus", false, Limits{MaxBolus: 2000}, func(p *Pump) { p.Bolus(2040) }, false},
+		{"duplicate_bolus", false, Limits{DuplicateBolusWindow: time.Hour}, func(p *Pump) { p.Bolus(1000); p.Bolus(1000) }, true},
+		{"rounded_duplicate_bolus", false, Limits{DuplicateBolusWindow: time.Hour}, func(p *Pump) { p.Bolus(1000); p.Bolus(1040) }, true},
+		{"different_bolus", false, Limits{DuplicateBolusWindow: time.Hour}, func(p *Pump) { p.Bolus(1000); p.Bolus(1500) }, true},
+		{"iob", false, Limits{MaxIOB: 3000}, func(p *Pump) { p.Bolus(2000); p.Bolus(1000) }, false},
+		{"iob_over_limit", false, Limits{MaxIOB: 3000}, func(p *Pump) { p.Bolus(2000); p.Bolus(1500) }, true},
+		{"temp_basal", false, Limits{MaxTempBasalMultiple: 2}, func(p *Pump) { p.SetAbsoluteTempBasal(time.Hour, 2000) }, false},
+		{"temp_basal_over_multiple", false, Limits{MaxTempBasalMultiple: 2}, func(p *Pump) { p.SetAbsoluteTempBasal(time.Hour, 2500) }, true},
+		{"temp_basal_over_iob", false, Limits{MaxIOB: 500}, func(p *Pump) { p.SetAbsoluteTempBasal(time.Hour, 1600) }, true},
+		{"percent_temp_basal", false, Limits{MaxTempBasalMultiple: 2, MaxIOB: 500}, func(p *Pump) { p.SetPercentTempBasal(time.Hour, 50) }, false},
+		{"basal_rates_over_limit", false, Limits{MaxBasal: 2500}, func(p *Pump) { p.SetBasalRates(BasalRateSchedule{{0, 3000}}) }, true},
+		{"max_bolus_over_limit", false, Limits{MaxBolus: 10000}, func(p *Pump) { p.SetMaxBolus(15000) }, true},
+		{"raw_bolus", false, Limits{MaxBolus: 2000}, func(p *Pump) { p.ExecuteRaw(0x42, 0x00, 0x28) }, true},
+		{"raw_temp_basal", false, Limits{MaxBasal: 2000}, func(p *Pump) { p.ExecuteRaw(0x4C, 0x00, 0x28, 0x02) }, true},
+		{"raw_query", false, Limits{MaxBolus: 2000}, func(p *Pump) { p.ExecuteRaw(0x73) }, false},
+		{"button", false, Limits{MaxBolus: 2000}, func(p *Pump) { p.Button(BolusButton) }, true},
+		{"button_allowed", false, Limits{AllowButtons: true}, func(p *Pump) { p.Button(EscButton) }, false},
+		{"set_clock", false, Limits{MaxBolus: 2000}, func(p *Pump) { p.SetClock(p.Clock()) }, true},
+		{"set_clock_allowed", false, Limits{AllowSetClock: true}, func(p *Pump) { p.SetClock(p.Clock()) }, false},
+		{"select_basal_pattern", false, Limits{MaxBasal: 2500}, func(p *Pump) { p.SelectBasalPattern(1) }, true},
+		{"select_basal_pattern_allowed", false, Limits{AllowSelectBasalPattern: true}, func(p *Pump) { p.SelectBasalPattern(1) }, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			config := simulator.DefaultConfig(testPumpID, "523")
+			config.Suspended = c.suspended
+			pump := simulatedPump(t, config)
+			pump.SetGuard(c.limits)
+			c.action(pump)
+			_, rejected := pump.Error().(GuardError)
+			if rejected != c.rejected {
+				t.Errorf("Error() == %v, want rejected = %v", pump.Error(), c.rejected)
+			}
+			if !rejected && pump.Error() != nil {
+				t.Error(pump.Error())
+			}
+		})
+	}
+}
+
+func TestGuardPumpError(t *testing.T) {
+	pump := simulatedPump(t, simulator.DefaultConfig(testPumpID, "523"))
+	pump.Family()
+	pump.SetGuard(Limits{MaxBolus: 2000})
+	// The guard's status query gets no response.
+	pump.Radio = &Radio{}
+	pump.Bolus(1000)
+	if _, ok := pump.Error().(NoResponseError); !ok {
+		t.Errorf("Error() == %v, want NoResponseError", pump.Error())
+	}
+}
+
+func TestGuardDecisionLog(t *testing.T) {
+	config := simulator.DefaultConfig(testPumpID, "523")
+	pump := simulatedPump(t, config)
+	var buf bytes.Buffer
+	pump.SetLogger(slog.New(slog.NewJSONHandler(&buf, nil)))
+	pump.SetGuard(Limits{MaxBolus: 2000})
+	pump.Bolus(2500)
+	if _, rejected := pump.Error().(GuardError); !rejected {
+		t.Fatalf("Error() == %v, want GuardError", pump.Error())
+	}
+	pump.SetError(nil)
+	if pump.Reservoir() != Insulin(config.Reservoir) {
+		t.Errorf("rejected bolus was delivered")
+	}
+	pump.Bolus(1500)
+	if pump.Error() != nil {
+		t.Fatal(pump.Error())
+	}
+	want := []struct {
+		msg     string
+		request string
+	}{
+		{"command rejected", "bolus 2.5"},
+		{"command allowed", "bolus 1.5"},
+	}
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var m map[string]interface{}
+		err := dec.Decode(&m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(want) != 0 && m["msg"] == want[0].msg && m["request"] == want[0].request {
+			want = want[1:]
+		}
+	}
+	if len(want) != 0 {
+		t.Errorf("no %q message for %q", want[0].msg, want[0].request)
+	}
+}
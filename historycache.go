@@ -0,0 +1,198 @@
+package medtronic
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/thecubic/medtronic/packet"
+)
+
+// HistoryCache holds pump history pages that have already been downloaded,
+// so that SyncHistory only needs to download the pages that changed.
+type HistoryCache struct {
+	Family Family
+	Pages  []CachedPage // most recent first
+}
+
+// CachedPage represents a downloaded history page.
+type CachedPage struct {
+	CRC  uint16
+	Data []byte // excluding CRC
+}
+
+func newCachedPage(data []byte) CachedPage {
+	return CachedPage{CRC: packet.CRC16(data), Data: data}
+}
+
+// ReadHistoryCache reads a history cache from a JSON file.
+// A nonexistent file results in an empty cache.
+func ReadHistoryCache(file string) (*HistoryCache, error) {
+	cache := &HistoryCache{}
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, cache)
+	if err != nil {
+		return nil, err
+	}
+	return cache, nil
+}
+
+// Write writes the history cache to a JSON file.
+func (cache *HistoryCache) Write(file string) error {
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, data, 0644)
+}
+
+// History decodes the cached pages and returns the records since the specified time.
+func (cache *HistoryCache) History(since time.Time) (History, error) {
+	scan := historyScan{family: cache.Family, since: since}
+	for _, p := range cache.Pages {
+		if scan.add(p) {
+			break
+		}
+	}
+	return scan.results, scan.err
+}
+
+// historyScan accumulates the records since a cutoff from successive pages,
+// decoding each page only once.
+type historyScan struct {
+	family  Family
+	since   time.Time
+	results History
+	done    bool
+	err     error
+}
+
+// add decodes the page and appends its records since the cutoff.
+// It returns true once a record that did not occur after the cutoff
+// has been found, or a page could not be decoded.
+func (scan *historyScan) add(p CachedPage) bool {
+	if scan.done {
+		return true
+	}
+	records, err := DecodeHistory(p.Data, scan.family)
+	i := findSince(records, scan.since)
+	scan.results = append(scan.results, records[:i]...)
+	if err != nil {
+		scan.err = err
+		scan.done = true
+	}
+	if i < len(records) {
+		scan.done = true
+	}
+	return scan.done
+}
+
+// continues returns true if p could be the result of appending records to the cached page c.
+// History pages are filled in place, so the records already in c must be a prefix of p.
+func (c CachedPage) continues(p CachedPage) bool {
+	used := bytes.TrimRight(c.Data, "\x00")
+	return len(used) != 0 && bytes.HasPrefix(p.Data, used)
+}
+
+// match checks whether a newly downloaded page corresponds to one in the cache.
+// If so, it returns the pages that follow the previously downloaded ones,
+// starting with the one corresponding to p.
+func (cache *HistoryCache) match(page int, p CachedPage) ([]CachedPage, bool) {
+	if len(cache.Pages) == 0 {
+		return nil, false
+	}
+	// The cached current page, possibly with more records added.
+	if cache.Pages[0].continues(p) {
+		return append([]CachedPage{p}, cache.Pages[1:]...), true
+	}
+	// The first cached page that was already full, in case the
+	// current page was empty when cached.
+	if page != 0 && len(cache.Pages) > 1 {
+		c := cache.Pages[1]
+		if p.CRC == c.CRC && bytes.Equal(p.Data, c.Data) {
+			return cache.Pages[1:], true
+		}
+	}
+	return nil, false
+}
+
+// SyncHistory updates the cache with any pump history pages that have changed
+// and returns the history records since the specified time.
+// Page 0 (the current page) is always downloaded. When it fills up,
+// the pump shifts each page to the next higher number, so pages are
+// downloaded until one matches the cache, and the remaining cached pages
+// are kept at their new positions.  No more pages are downloaded once
+// they reach back to the specified time, even if none matched the cache,
+// except that page 1 is still compared with the cache in case the pump
+// has just started a new page.  Older pages are downloaded only if
+// the cache does not reach back that far.
+func (pump *Pump) SyncHistory(cache *HistoryCache, since time.Time) History {
+	family := pump.Family()
+	count := pump.HistoryPageCount()
+	if pump.Error() != nil {
+		return nil
+	}
+	if cache.Family != family {
+		cache.Pages = nil
+		cache.Family = family
+	}
+	scan := historyScan{family: family, since: since}
+	var pages []CachedPage
+	matched := false
+	scanned := 0
+	for page := 0; page < count; page++ {
+		data := pump.HistoryPage(page)
+		if pump.Error() != nil {
+			return nil
+		}
+		p := newCachedPage(data)
+		rest, found := cache.match(page, p)
+		if found {
+			if page != 0 {
+				pump.Logger().Info("history cache matched", "page", page)
+			}
+			pages = append(pages, rest...)
+			matched = true
+			break
+		}
+		pages = append(pages, p)
+		scanned++
+		if scan.add(p) && (page != 0 || len(cache.Pages) == 0) {
+			break
+		}
+	}
+	if !matched && len(cache.Pages) != 0 {
+		pump.Logger().Info("history cache discarded", "pages", len(cache.Pages))
+	}
+	if len(pages) > count {
+		pages = pages[:count]
+	}
+	cache.Pages = pages
+	for _, p := range cache.Pages[scanned:] {
+		if scan.add(p) {
+			break
+		}
+	}
+	// Extend the cache if it does not reach back far enough.
+	for page := len(cache.Pages); page < count && !scan.done; page++ {
+		data := pump.HistoryPage(page)
+		if pump.Error() != nil {
+			return nil
+		}
+		p := newCachedPage(data)
+		cache.Pages = append(cache.Pages, p)
+		scan.add(p)
+	}
+	if scan.err != nil {
+		pump.SetError(scan.err)
+	}
+	return scan.results
+}
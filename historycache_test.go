@@ -0,0 +1,161 @@
+package medtronic
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ecc1/radio"
+	"github.com/thecubic/medtronic/packet"
+	"github.com/thecubic/medtronic/simulator"
+)
+
+// pageCountingRadio counts the history pages requested from the pump.
+type pageCountingRadio struct {
+	radio.Interface
+	pages int
+}
+
+func (r *pageCountingRadio) SendAndReceive(data []byte, timeout time.Duration) ([]byte, int) {
+	p, err := packet.Decode(data)
+	if err == nil && len(p) == longPacketLength && Command(p[4]) == historyPage {
+		r.pages++
+	}
+	return r.Interface.SendAndReceive(data, timeout)
+}
+
+func TestSyncHistory(t *testing.T) {
+	pump := simulatedPump(t, simulator.DefaultConfig(testPumpID, "523"))
+	r := &pageCountingRadio{Interface: pump.Radio}
+	pump.Radio = r
+	addRecords := func(n int) {
+		for i := 0; i < n; i++ {
+			pump.SetAbsoluteTempBasal(30*time.Minute, Insulin(100*(i%10)))
+		}
+		if pump.Error() != nil {
+			t.Fatal(pump.Error())
+		}
+	}
+	file := filepath.Join(t.TempDir(), "history.json")
+	cases := []struct {
+		records int // temp basals to add before syncing
+		pages   int // expected page downloads
+	}{
+		{10, 1},  // empty cache
+		{5, 1},   // current page continued
+		{0, 1},   // no change
+		{80, 2},  // one page rotation
+		{160, 3}, // two page rotation
+		{1, 1},
+	}
+	for _, c := range cases {
+		addRecords(c.records)
+		cache, err := ReadHistoryCache(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.pages = 0
+		got := pump.SyncHistory(cache, time.Time{})
+		if pump.Error() != nil {
+			t.Fatal(pump.Error())
+		}
+		if r.pages != c.pages {
+			t.Errorf("SyncHistory after %d records downloaded %d pages, want %d", c.records, r.pages, c.pages)
+		}
+		want := pump.History(time.Time{})
+		if pump.Error() != nil {
+			t.Fatal(pump.Error())
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("SyncHistory after %d records returned %d records, want %d", c.records, len(got), len(want))
+		}
+		err = cache.Write(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSyncHistoryStaleCache(t *testing.T) {
+	pump := simulatedPump(t, simulator.DefaultConfig(testPumpID, "523"))
+	r := &pageCountingRadio{Interface: pump.Radio}
+	pump.Radio = r
+	for i := 0; i < 240; i++ {
+		pump.SetAbsoluteTempBasal(30*time.Minute, Insulin(100*(i%10)))
+	}
+	since := pump.Clock().Add(time.Hour)
+	pump.SetClock(since.Add(time.Hour))
+	pump.SetAbsoluteTempBasal(30*time.Minute, 500)
+	if pump.Error() != nil {
+		t.Fatal(pump.Error())
+	}
+	count := pump.HistoryPageCount()
+	if count < 3 {
+		t.Fatalf("HistoryPageCount() == %d, want at least 3", count)
+	}
+	// Pages from another pump, which do not match any current page.
+	cache := &HistoryCache{
+		Family: pump.Family(),
+		Pages:  []CachedPage{newCachedPage([]byte{1, 2, 3}), newCachedPage([]byte{4, 5, 6})},
+	}
+	r.pages = 0
+	got := pump.SyncHistory(cache, since)
+	if pump.Error() != nil {
+		t.Fatal(pump.Error())
+	}
+	// Page 1 is still compared with the cache.
+	if r.pages != 2 {
+		t.Errorf("SyncHistory with stale cache downloaded %d pages, want 2", r.pages)
+	}
+	want := pump.History(since)
+	if pump.Error() != nil {
+		t.Fatal(pump.Error())
+	}
+	if len(want) == 0 || !reflect.DeepEqual(got, want) {
+		t.Errorf("SyncHistory with stale cache returned %d records, want %d", len(got), len(want))
+	}
+}
+
+func TestSyncHistoryNewPage(t *testing.T) {
+	pump := simulatedPump(t, simulator.DefaultConfig(testPumpID, "523"))
+	r := &pageCountingRadio{Interface: pump.Radio}
+	pump.Radio = r
+	pump.SetAbsoluteTempBasal(30*time.Minute, 1500)
+	cache := &HistoryCache{}
+	pump.SyncHistory(cache, time.Time{})
+	if pump.Error() != nil {
+		t.Fatal(pump.Error())
+	}
+	// Fill the current page until the pump starts a new one.
+	for i := 0; pump.HistoryPageCount() < 2; i++ {
+		pump.SetAbsoluteTempBasal(30*time.Minute, Insulin(100*(i%10)))
+		if pump.Error() != nil {
+			t.Fatal(pump.Error())
+		}
+	}
+	since := pump.Clock().Add(time.Hour)
+	pump.SetClock(since.Add(time.Hour))
+	pump.SetAbsoluteTempBasal(30*time.Minute, 1000)
+	if pump.Error() != nil {
+		t.Fatal(pump.Error())
+	}
+	r.pages = 0
+	got := pump.SyncHistory(cache, since)
+	if pump.Error() != nil {
+		t.Fatal(pump.Error())
+	}
+	if r.pages != 2 {
+		t.Errorf("SyncHistory after new page downloaded %d pages, want 2", r.pages)
+	}
+	if len(cache.Pages) != 2 {
+		t.Errorf("SyncHistory after new page kept %d cached pages, want 2", len(cache.Pages))
+	}
+	want := pump.History(since)
+	if pump.Error() != nil {
+		t.Fatal(pump.Error())
+	}
+	if len(want) == 0 || !reflect.DeepEqual(got, want) {
+		t.Errorf("SyncHistory after new page returned %d records, want %d", len(got), len(want))
+	}
+}
@@ -256,6 +256,63 @@ type (
 
 	UnabsorbedBolusHistory []UnabsorbedBolus
 
+	OtherDevice struct {
+		Type int
+		ID   string
+	}
+
+	SensorSettings struct {
+		Enabled       bool
+		TransmitterID string `json:",omitempty"`
+		HighSnooze    Duration
+		LowSnooze     Duration
+		HighLimit     Glucose `json:",omitempty"` // 0 if off
+		LowLimit      Glucose `json:",omitempty"` // 0 if off
+	}
+
+	SensorSetupRecord struct {
+		Before SensorSettings
+		After  SensorSettings
+	}
+
+	SensorAlarmSilenceRecord struct {
+		Mode     int
+		Duration Duration
+	}
+
+	SensorLimit struct {
+		Start TimeOfDay
+		High  Glucose
+		Low   Glucose
+	}
+
+	SensorLimitSchedule []SensorLimit
+
+	SensorLimitsRecord struct {
+		Before SensorLimitSchedule
+		After  SensorLimitSchedule
+	}
+
+	// Predictive alerts are given as the time before a limit
+	// is expected to be reached (0 if off).
+	PredictiveAlert struct {
+		Start TimeOfDay
+		High  Duration
+		Low   Duration
+	}
+
+	PredictiveAlertSchedule []PredictiveAlert
+
+	PredictiveAlertsRecord struct {
+		Before PredictiveAlertSchedule
+		After  PredictiveAlertSchedule
+	}
+
+	RateOfChangeRecord struct {
+		RiseLimit float64 // mg/dL per minute
+		FallLimit float64 // mg/dL per minute
+	}
+
 	UnknownRecordTypeError struct {
 		Data []byte
 	}
@@ -485,7 +542,17 @@ var decodeMaxBasal = decodeInsulin
 
 var decodeEnableBolusWizard = decodeEnable
 
-var decodeUnknown2E = decodeBaseN(107)
+// Bolus wizard setup on 512 pumps.
+func decodeUnknown2E(data []byte, family Family) HistoryRecord {
+	r := decodeBase(data, family)
+	body := data[7:107]
+	r.Info = BolusWizardSetupRecord{
+		Before: decodeBolusWizardConfig(body[:50], family),
+		After:  decodeBolusWizardConfig(body[50:], family),
+	}
+	r.Data = data[:107]
+	return r
+}
 
 func decodeBolusWizard512(data []byte, family Family) HistoryRecord {
 	r := decodeBase(data, family)
@@ -501,10 +568,10 @@ func decodeBolusWizard512(data []byte, family Family) HistoryRecord {
 		TargetLow:    byteToGlucose(body[4], bgU),
 		Sensitivity:  byteToGlucose(body[3], bgU),
 		CarbRatio:    intToRatio(int(body[2]), carbU, family),
-		Correction:   intToInsulin(int(body[7])+int(body[5]&0xF), 12),
-		Food:         byteToInsulin(body[6], 12),
-		Unabsorbed:   byteToInsulin(body[9], 12),
-		Bolus:        byteToInsulin(body[11], 12),
+		Correction:   bolusWizardCorrection(body, 12),
+		Food:         intToInsulin(int(body[7]&0xF)<<8|int(body[6]), 12),
+		Unabsorbed:   twoByteInsulin(body[8:10], 12),
+		Bolus:        twoByteInsulin(body[10:12], 12),
 	}
 	info.TargetHigh = info.TargetLow
 	r.Info = info
@@ -586,27 +653,134 @@ var decodeEnableSensorAutoCal = decodeBase
 
 var decodeChangeBolusWizardSetup = decodeBaseN(39)
 
+// The layouts of the sensor settings records were inferred from
+// the records in testdata; bytes whose meaning is unknown are only kept in Data.
+
+func decodeSensorSettings(data []byte, family Family) SensorSettings {
+	s := SensorSettings{
+		Enabled:    data[0]&0x1 != 0,
+		HighSnooze: minutesToDuration(data[4]),
+		LowSnooze:  minutesToDuration(data[5]),
+	}
+	id := int(data[9])<<16 | int(data[10])<<8 | int(data[11])
+	if id != 0 && id != 0xFFFFFF {
+		s.TransmitterID = fmt.Sprintf("%d", id)
+	}
+	if family <= 23 {
+		// Later pumps use the schedule in Sensor54 records instead.
+		s.HighLimit = byteToGlucose(data[12], MgPerDeciLiter)
+		s.LowLimit = byteToGlucose(data[13], MgPerDeciLiter)
+	}
+	return s
+}
+
 func decodeSensorSetup(data []byte, family Family) HistoryRecord {
-	var d decoder
+	r := decodeBase(data, family)
+	n := 15
 	if family >= 51 {
-		d = decodeBaseN(41)
-	} else {
-		d = decodeBaseN(37)
+		n = 17
 	}
-	return d(data, family)
+	body := data[7:]
+	r.Info = SensorSetupRecord{
+		Before: decodeSensorSettings(body[:n], family),
+		After:  decodeSensorSettings(body[n:2*n], family),
+	}
+	r.Data = data[:7+2*n]
+	return r
 }
 
-var decodeSensor51 = decodeBase
+// Calibration reminder interval.
+func decodeSensor51(data []byte, family Family) HistoryRecord {
+	r := decodeBase(data, family)
+	r.Info = hoursToDuration(data[1])
+	return r
+}
 
-var decodeSensor52 = decodeBase
+// Calibration reminder enabled.
+var decodeSensor52 = decodeEnable
 
-var decodeChangeSensorAlarm = decodeBaseN(8)
+func decodeChangeSensorAlarm(data []byte, family Family) HistoryRecord {
+	r := decodeBase(data, family)
+	r.Info = SensorAlarmSilenceRecord{
+		Mode:     int(data[1]),
+		Duration: minutesToDuration(data[7]),
+	}
+	r.Data = data[:8]
+	return r
+}
 
-var decodeSensor54 = decodeBaseN(64)
+// Schedule entries are 3 bytes: start time in half-hours and two values.
+// Unused entries have 0xFF values.
+func sensorScheduleEntries(data []byte) [][]byte {
+	var entries [][]byte
+	for i := 0; i+3 <= len(data); i += 3 {
+		e := data[i : i+3]
+		if e[1] == 0xFF && e[2] == 0xFF {
+			break
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
 
-var decodeSensor55 = decodeBaseN(55)
+func decodeSensorLimitSchedule(data []byte) SensorLimitSchedule {
+	var sched SensorLimitSchedule
+	for _, e := range sensorScheduleEntries(data) {
+		sched = append(sched, SensorLimit{
+			Start: halfHoursToTimeOfDay(e[0]),
+			High:  byteToGlucose(e[1], MgPerDeciLiter),
+			Low:   byteToGlucose(e[2], MgPerDeciLiter),
+		})
+	}
+	return sched
+}
+
+// Glucose limit schedule.
+func decodeSensor54(data []byte, family Family) HistoryRecord {
+	r := decodeBase(data, family)
+	body := data[7:]
+	r.Info = SensorLimitsRecord{
+		Before: decodeSensorLimitSchedule(body[3:27]),
+		After:  decodeSensorLimitSchedule(body[31:55]),
+	}
+	r.Data = data[:64]
+	return r
+}
+
+func decodePredictiveAlertSchedule(data []byte) PredictiveAlertSchedule {
+	var sched PredictiveAlertSchedule
+	for _, e := range sensorScheduleEntries(data) {
+		sched = append(sched, PredictiveAlert{
+			Start: halfHoursToTimeOfDay(e[0]),
+			High:  minutesToDuration(e[1]),
+			Low:   minutesToDuration(e[2]),
+		})
+	}
+	return sched
+}
+
+// Predictive alert schedule.
+func decodeSensor55(data []byte, family Family) HistoryRecord {
+	r := decodeBase(data, family)
+	body := data[7:]
+	r.Info = PredictiveAlertsRecord{
+		Before: decodePredictiveAlertSchedule(body[0:24]),
+		After:  decodePredictiveAlertSchedule(body[24:48]),
+	}
+	r.Data = data[:55]
+	return r
+}
 
-var decodeChangeSensorAlert = decodeBaseN(12)
+// Rate-of-change alert limits.
+func decodeChangeSensorAlert(data []byte, family Family) HistoryRecord {
+	r := decodeBase(data, family)
+	r.Info = RateOfChangeRecord{
+		RiseLimit: float64(data[10]) / 10,
+		FallLimit: float64(data[11]) / 10,
+	}
+	r.Data = data[:12]
+	return r
+}
 
 var decodeChangeBolusStep = decodeBase
 
@@ -632,7 +806,7 @@ func decodeBolusWizardConfig(data []byte, family Family) BolusWizardConfig {
 	} else {
 		data = data[numEntries*2+2:]
 	}
-	r.Targets = decodeGlucoseTargetSchedule(data[:numEntries*3], bgUnits, family)
+	r.Targets = decodeGlucoseTargetSchedule(data[:numEntries*glucoseTargetStep(family)], bgUnits, family)
 	return r
 }
 
@@ -673,10 +847,10 @@ func decodeBolusWizard(data []byte, family Family) HistoryRecord {
 			TargetHigh:   byteToGlucose(body[12], bgU),
 			Sensitivity:  byteToGlucose(body[3], bgU),
 			CarbRatio:    intToRatio(int(body[2]), carbU, family),
-			Correction:   intToInsulin(int(body[7])+int(body[5]&0xF), family),
-			Food:         byteToInsulin(body[6], family),
-			Unabsorbed:   byteToInsulin(body[9], family),
-			Bolus:        byteToInsulin(body[11], family),
+			Correction:   bolusWizardCorrection(body, family),
+			Food:         intToInsulin(int(body[7]&0xF)<<8|int(body[6]), family),
+			Unabsorbed:   twoByteInsulin(body[8:10], family),
+			Bolus:        twoByteInsulin(body[10:12], family),
 		}
 		r.Data = data[:20]
 	} else {
@@ -699,6 +873,17 @@ func decodeBolusWizard(data []byte, family Family) HistoryRecord {
 	return r
 }
 
+// On older pumps, the correction is a 12-bit signed value,
+// with the high 4 bits sharing a byte with those of the food estimate.
+// It is negative when the glucose input is below the target.
+func bolusWizardCorrection(body []byte, family Family) Insulin {
+	n := int(body[7]>>4)<<8 | int(body[5])
+	if n&0x800 != 0 {
+		n -= 0x1000
+	}
+	return intToInsulin(n, family)
+}
+
 func decodeUnabsorbedInsulin(data []byte, family Family) HistoryRecord {
 	n := int(data[1]) - 2
 	body := data[2:]
@@ -706,7 +891,7 @@ func decodeUnabsorbedInsulin(data []byte, family Family) HistoryRecord {
 	for i := 0; i < n; i += 3 {
 		amount := byteToInsulin(body[i], 23)
 		curve := body[i+2]
-		age := minutesToDuration(body[i+1] + (curve&0x30)<<4)
+		age := Duration(time.Duration(int(body[i+1])+int(curve&0x30)<<4) * time.Minute)
 		unabsorbed = append(unabsorbed, UnabsorbedBolus{
 			Bolus: amount,
 			Age:   age,
@@ -779,11 +964,38 @@ func decodeBasalProfileStart(data []byte, family Family) HistoryRecord {
 
 var decodeConnectOtherDevices = decodeEnable
 
-var decodeChangeOtherDevice = decodeBaseN(37)
+// Other devices are identified by a type byte followed by a 4-byte ID.
+func decodeOtherDevice(data []byte) OtherDevice {
+	return OtherDevice{
+		Type: int(data[0]),
+		ID:   fmt.Sprintf("%X", data[1:5]),
+	}
+}
+
+// The record lists up to 6 devices; data[1] is the number in use.
+func decodeChangeOtherDevice(data []byte, family Family) HistoryRecord {
+	r := decodeBase(data, family)
+	n := int(data[1])
+	if n > 6 {
+		n = 6
+	}
+	devices := []OtherDevice{}
+	for i := 0; i < n; i++ {
+		devices = append(devices, decodeOtherDevice(data[7+5*i:12+5*i]))
+	}
+	r.Info = devices
+	r.Data = data[:37]
+	return r
+}
 
-var decodeChangeMarriage = decodeBaseN(12)
+func decodeChangeMarriage(data []byte, family Family) HistoryRecord {
+	r := decodeBase(data, family)
+	r.Info = decodeOtherDevice(data[7:12])
+	r.Data = data[:12]
+	return r
+}
 
-var decodeDeleteOtherDevice = decodeBaseN(12)
+var decodeDeleteOtherDevice = decodeChangeMarriage
 
 var decodeEnableCaptureEvent = decodeEnable
 
@@ -0,0 +1,176 @@
+package medtronic
+
+import (
+	"math"
+	"time"
+)
+
+// InsulinCurve selects a model of how much of a dose of insulin remains active over time.
+// Each curve is scaled to the insulin action duration.
+type InsulinCurve int
+
+const (
+	// LinearCurve decreases linearly over the insulin action duration.
+	LinearCurve InsulinCurve = iota
+	// BilinearCurve has insulin activity that rises linearly to a peak and then falls linearly to zero.
+	// The peak is at 75 minutes for a 3-hour action duration, as in oref0.
+	BilinearCurve
+	// ExponentialCurve is the oref0 exponential curve.
+	// The peak is at 75 minutes for a 5-hour action duration.
+	ExponentialCurve
+	// WalshCurve is the curve used by UnabsorbedBolusHistory,
+	// which approximates the pump's bolus wizard.
+	WalshCurve
+)
+
+const (
+	// Extended boluses and basal deliveries are divided into doses at this interval.
+	doseInterval = 5 * time.Minute
+)
+
+// Remaining returns the fraction of a dose of insulin that remains active
+// the given time after it was delivered.
+func (c InsulinCurve) Remaining(elapsed time.Duration, action time.Duration) float64 {
+	if elapsed <= 0 {
+		return 1
+	}
+	if elapsed >= action {
+		return 0
+	}
+	t := float64(elapsed)
+	td := float64(action)
+	switch c {
+	case BilinearCurve:
+		tp := td * 75 / 180
+		if t < tp {
+			return 1 - t*t/(tp*td)
+		}
+		return (td - t) * (td - t) / (td * (td - tp))
+	case ExponentialCurve:
+		tp := td * 75 / 300
+		tau := tp * (1 - tp/td) / (1 - 2*tp/td)
+		a := 2 * tau / td
+		s := 1 / (1 - a + (1+a)*math.Exp(-td/tau))
+		return 1 - s*(1-a)*((t*t/(tau*td*(1-a))-t/tau-1)*math.Exp(-t/tau)+1)
+	case WalshCurve:
+		return walshRemaining(elapsed, action)
+	default:
+		return 1 - t/td
+	}
+}
+
+// InsulinOnBoard represents the insulin remaining active at a given time.
+type InsulinOnBoard struct {
+	Time  time.Time
+	Bolus Insulin // from boluses
+	Basal Insulin // from temp basals and suspends, relative to the scheduled basal rates
+}
+
+// Total returns the combined bolus and basal insulin on board.
+func (iob InsulinOnBoard) Total() Insulin {
+	return iob.Bolus + iob.Basal
+}
+
+// rate returns the rate in milliUnits per hour delivered by a temp basal,
+// given the scheduled basal rate.
+func (tb TempBasalRecord) rate(scheduled float64) float64 {
+	if tb.Type == Percent {
+		return scheduled * float64(tb.Value.(int)) / 100
+	}
+	return float64(tb.Value.(Insulin))
+}
+
+// spreadDose divides the interval from start to end (or the cutoff time, if that is earlier)
+// into doses, calls fn with the start and length of each one, and returns the sum of the results.
+func spreadDose(start time.Time, end time.Time, cutoff time.Time, fn func(time.Time, time.Duration) float64) float64 {
+	if end.After(cutoff) {
+		end = cutoff
+	}
+	sum := 0.0
+	for t := start; t.Before(end); t = t.Add(doseInterval) {
+		length := doseInterval
+		if t.Add(length).After(end) {
+			length = end.Sub(t)
+		}
+		sum += fn(t, length)
+	}
+	return sum
+}
+
+// ComputeInsulinOnBoard computes the insulin on board at time t from history records,
+// which must be in chronological order.
+// Square wave boluses are spread over the time it took to deliver them.
+// Temp basals and suspensions contribute the difference between the insulin delivered
+// and the scheduled basal rate, which may be negative.
+func ComputeInsulinOnBoard(records History, basal BasalRateSchedule, curve InsulinCurve, action time.Duration, t time.Time) InsulinOnBoard {
+	remaining := func(amount float64, at time.Time) float64 {
+		return amount * curve.Remaining(t.Sub(at), action)
+	}
+	timeline := ComputeDeliveryTimeline(records, BasalPatterns{basal}, 0, t)
+	bolus := 0.0
+	for _, b := range timeline.Boluses {
+		if b.Rate == 0 {
+			bolus += remaining(float64(b.Amount), b.Start)
+			continue
+		}
+		rate := float64(b.Rate) / float64(time.Hour)
+		bolus += spreadDose(b.Start, b.End, t, func(start time.Time, length time.Duration) float64 {
+			return remaining(rate*float64(length), start.Add(length/2))
+		})
+	}
+	basalIOB := 0.0
+	for _, seg := range timeline.Basal {
+		if seg.Type == ScheduledDelivery {
+			continue
+		}
+		delivered := float64(seg.Rate)
+		basalIOB += spreadDose(seg.Start, seg.End, t, func(start time.Time, length time.Duration) float64 {
+			mid := start.Add(length / 2)
+			scheduled := float64(basal.BasalRateAt(mid).Rate)
+			return remaining((delivered-scheduled)*float64(length)/float64(time.Hour), mid)
+		})
+	}
+	return InsulinOnBoard{
+		Time:  t,
+		Bolus: Insulin(math.Round(bolus)),
+		Basal: Insulin(math.Round(basalIOB)),
+	}
+}
+
+// InsulinOnBoard returns the insulin on board at the pump's current time,
+// using the pump's insulin action setting and its selected basal pattern.
+// The history is read back far enough before the insulin action duration
+// to include a temp basal of the maximum duration that is still in effect,
+// but suspensions that began before the insulin action duration are not taken into account.
+func (pump *Pump) InsulinOnBoard(curve InsulinCurve) InsulinOnBoard {
+	settings := pump.Settings()
+	if pump.Error() != nil {
+		return InsulinOnBoard{}
+	}
+	basal := pump.selectedBasalRates(settings.SelectedPattern)
+	if pump.Error() != nil {
+		return InsulinOnBoard{}
+	}
+	t := pump.Clock()
+	if pump.Error() != nil {
+		return InsulinOnBoard{}
+	}
+	records := pump.History(t.Add(-settings.InsulinAction - maxDuration))
+	if pump.Error() != nil {
+		return InsulinOnBoard{}
+	}
+	ReverseHistory(records)
+	return ComputeInsulinOnBoard(records, basal, curve, settings.InsulinAction, t)
+}
+
+// selectedBasalRates returns the basal rate schedule for the given pattern.
+func (pump *Pump) selectedBasalRates(pattern int) BasalRateSchedule {
+	switch pattern {
+	case 1:
+		return pump.BasalPatternA()
+	case 2:
+		return pump.BasalPatternB()
+	default:
+		return pump.BasalRates()
+	}
+}
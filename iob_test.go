@@ -0,0 +1,167 @@
+package medtronic
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/thecubic/medtronic/simulator"
+)
+
+func TestInsulinCurves(t *testing.T) {
+	const action = 3 * time.Hour
+	cases := []struct {
+		curve   InsulinCurve
+		elapsed time.Duration
+		frac    float64
+	}{
+		{LinearCurve, 0, 1},
+		{LinearCurve, 90 * time.Minute, 0.5},
+		{LinearCurve, 3 * time.Hour, 0},
+		{BilinearCurve, 0, 1},
+		{BilinearCurve, 75 * time.Minute, 1 - 75.0/180},
+		{BilinearCurve, 3 * time.Hour, 0},
+		{ExponentialCurve, 0, 1},
+		{ExponentialCurve, 3 * time.Hour, 0},
+		{WalshCurve, 0, 1},
+		{WalshCurve, 3 * time.Hour, 0},
+	}
+	for _, c := range cases {
+		f := c.curve.Remaining(c.elapsed, action)
+		if math.Abs(f-c.frac) > 1e-9 {
+			t.Errorf("curve %d: Remaining(%v) == %v, want %v", c.curve, c.elapsed, f, c.frac)
+		}
+	}
+	for _, curve := range []InsulinCurve{LinearCurve, BilinearCurve, ExponentialCurve, WalshCurve} {
+		last := 1.0
+		for e := time.Minute; e <= action; e += time.Minute {
+			f := curve.Remaining(e, action)
+			if f > last || f < 0 {
+				t.Errorf("curve %d: Remaining(%v) == %v after %v", curve, e, f, last)
+				break
+			}
+			last = f
+		}
+	}
+}
+
+func testRecord(t HistoryRecordType, at string, info interface{}) HistoryRecord {
+	return HistoryRecord{Data: []byte{byte(t)}, Time: parseTime("2018-06-20T" + at), Info: info}
+}
+
+func tempBasalRecords(at string, rate Insulin, d time.Duration) History {
+	return History{
+		testRecord(TempBasalRate, at, TempBasalRecord{Type: Absolute, Value: rate}),
+		testRecord(TempBasalDuration, at, Duration(d)),
+	}
+}
+
+func concat(hs ...History) History {
+	var records History
+	for _, h := range hs {
+		records = append(records, h...)
+	}
+	return records
+}
+
+func TestComputeInsulinOnBoard(t *testing.T) {
+	basal := BasalRateSchedule{{Start: 0, Rate: 1000}}
+	cases := []struct {
+		records History
+		bolus   Insulin
+		basal   Insulin
+	}{
+		{
+			History{testRecord(Bolus, "11:00", BolusRecord{Programmed: 3000, Amount: 3000})},
+			2000, 0,
+		},
+		{
+			History{testRecord(Bolus, "10:30", BolusRecord{Programmed: 1200, Amount: 1200, Duration: Duration(time.Hour)})},
+			800, 0,
+		},
+		{
+			// Canceled after 30 minutes.
+			History{testRecord(Bolus, "11:00", BolusRecord{Programmed: 1200, Amount: 600, Duration: Duration(time.Hour)})},
+			450, 0,
+		},
+		{
+			tempBasalRecords("11:00", 0, 30*time.Minute),
+			0, -375,
+		},
+		{
+			tempBasalRecords("11:00", 2000, 2*time.Hour),
+			0, 833,
+		},
+		{
+			History{
+				testRecord(TempBasalRate, "11:00", TempBasalRecord{Type: Percent, Value: 150}),
+				testRecord(TempBasalDuration, "11:00", Duration(time.Hour)),
+			},
+			0, 417,
+		},
+		{
+			// Canceled temp basal.
+			concat(tempBasalRecords("11:00", 0, time.Hour), tempBasalRecords("11:30", 0, 0)),
+			0, -375,
+		},
+		{
+			History{
+				testRecord(SuspendPump, "11:00", nil),
+				testRecord(ResumePump, "11:30", nil),
+			},
+			0, -375,
+		},
+		{
+			// Suspended during a temp basal.
+			concat(
+				tempBasalRecords("11:00", 2000, time.Hour),
+				History{
+					testRecord(SuspendPump, "11:30", nil),
+					testRecord(ResumePump, "11:45", nil),
+				},
+			),
+			0, 396,
+		},
+		{
+			// Records after the cutoff are ignored.
+			History{
+				testRecord(Bolus, "11:00", BolusRecord{Programmed: 3000, Amount: 3000}),
+				testRecord(Bolus, "12:30", BolusRecord{Programmed: 3000, Amount: 3000}),
+			},
+			2000, 0,
+		},
+	}
+	now := parseTime("2018-06-20T12:00")
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("case%d", i+1), func(t *testing.T) {
+			iob := ComputeInsulinOnBoard(c.records, basal, LinearCurve, 3*time.Hour, now)
+			if iob.Bolus != c.bolus || iob.Basal != c.basal {
+				t.Errorf("ComputeInsulinOnBoard == %v bolus + %v basal, want %v + %v", iob.Bolus, iob.Basal, c.bolus, c.basal)
+			}
+		})
+	}
+}
+
+func TestPumpInsulinOnBoard(t *testing.T) {
+	// Start a temp basal 4 hours before the current time,
+	// before the 3-hour insulin action duration.
+	config := simulator.DefaultConfig(testPumpID, "523")
+	config.ClockOffset = -4 * time.Hour
+	pump := simulatedPump(t, config)
+	pump.SetAbsoluteTempBasal(8*time.Hour, 2000)
+	if pump.Error() != nil {
+		t.Fatal(pump.Error())
+	}
+	config = pump.Radio.(*simulator.Pump).Config()
+	config.ClockOffset = 0
+	pump = simulatedPump(t, config)
+	iob := pump.InsulinOnBoard(LinearCurve)
+	if pump.Error() != nil {
+		t.Fatal(pump.Error())
+	}
+	// 1 U/hr above the scheduled rate throughout the insulin action duration.
+	if math.Abs(float64(iob.Basal-1500)) > 20 || iob.Bolus != 0 {
+		t.Errorf("InsulinOnBoard == %v bolus + %v basal, want 0 + 1.5", iob.Bolus, iob.Basal)
+	}
+}
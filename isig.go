@@ -0,0 +1,176 @@
+package medtronic
+
+import (
+	"bytes"
+	"time"
+)
+
+// EXPERIMENTAL: the ISIG and vcntr page layout below is assumed,
+// and has not been verified against pages captured from a pump.
+// Until it is, the decoded values should not be relied on,
+// so the decoders are not exported or used by the commands.
+//
+// The ISIG and vcntr pages are assumed to parallel the glucose page with the same number:
+// an ISIG page has 2 bytes (big-endian) and a vcntr page has 1 byte
+// for each byte of the glucose page.  The measurement corresponding to
+// a glucose record is assumed to be stored at the position of the record's type code,
+// which is the last byte of the record as stored in the page.
+
+type (
+	// isigRecord represents a raw sensor current (ISIG) measurement.
+	isigRecord struct {
+		Time time.Time
+		Type CGMRecordType // type of the corresponding glucose record
+		ISIG int
+	}
+
+	// isigHistory represents a sequence of ISIG records.
+	isigHistory []isigRecord
+
+	// vcntrRecord represents a raw sensor counter electrode voltage measurement.
+	vcntrRecord struct {
+		Time  time.Time
+		Type  CGMRecordType // type of the corresponding glucose record
+		Vcntr int
+	}
+
+	// vcntrHistory represents a sequence of vcntr records.
+	vcntrHistory []vcntrRecord
+)
+
+// sensorRecord is a glucose record with its position in the stored page.
+type sensorRecord struct {
+	CGMRecord
+	offset int
+}
+
+// sensorRecords returns the timed relative records from a decoded glucose page,
+// most recent first, along with the positions of their type codes in the page.
+// The used argument is the number of bytes in use on the page.
+func sensorRecords(records CGMHistory, used int) []sensorRecord {
+	var results []sensorRecord
+	end := used
+	for _, r := range records {
+		end -= len(r.Data)
+		if r.Type.isRelative() && !r.Time.IsZero() {
+			results = append(results, sensorRecord{CGMRecord: r, offset: end + len(r.Data) - 1})
+		}
+	}
+	return results
+}
+
+// decodeGlucoseForSensor decodes a glucose page (without modifying it)
+// and returns its timed relative records and the timestamp for the preceding page.
+func decodeGlucoseForSensor(glucose []byte, t time.Time) ([]sensorRecord, time.Time, error) {
+	used := len(bytes.TrimRight(glucose, "\x00"))
+	data := make([]byte, len(glucose))
+	copy(data, glucose)
+	records, t, err := DecodeCGMHistory(data, t)
+	return sensorRecords(records, used), t, err
+}
+
+func isigRecords(isig []byte, records []sensorRecord) isigHistory {
+	var results isigHistory
+	for _, r := range records {
+		i := 2 * r.offset
+		if i+2 > len(isig) {
+			continue
+		}
+		results = append(results, isigRecord{
+			Time: r.Time,
+			Type: r.Type,
+			ISIG: int(twoByteUint(isig[i : i+2])),
+		})
+	}
+	return results
+}
+
+func vcntrRecords(vcntr []byte, records []sensorRecord) vcntrHistory {
+	var results vcntrHistory
+	for _, r := range records {
+		if r.offset >= len(vcntr) {
+			continue
+		}
+		results = append(results, vcntrRecord{
+			Time:  r.Time,
+			Type:  r.Type,
+			Vcntr: int(vcntr[r.offset]),
+		})
+	}
+	return results
+}
+
+// decodeISIGHistory decodes an ISIG page using the glucose page with the same number
+// and returns the records in reverse chronological order (most recent first).
+// The page layout is experimental (see above).
+// As with DecodeCGMHistory, a non-zero time is used as the initial timestamp,
+// and the timestamp for decoding the preceding page is returned.
+func decodeISIGHistory(isig []byte, glucose []byte, t time.Time) (isigHistory, time.Time, error) {
+	records, t, err := decodeGlucoseForSensor(glucose, t)
+	return isigRecords(isig, records), t, err
+}
+
+// decodeVcntrHistory decodes a vcntr page using the glucose page with the same number
+// and returns the records in reverse chronological order (most recent first).
+// The page layout is experimental (see above).
+// As with DecodeCGMHistory, a non-zero time is used as the initial timestamp,
+// and the timestamp for decoding the preceding page is returned.
+func decodeVcntrHistory(vcntr []byte, glucose []byte, t time.Time) (vcntrHistory, time.Time, error) {
+	records, t, err := decodeGlucoseForSensor(glucose, t)
+	return vcntrRecords(vcntr, records), t, err
+}
+
+// sensorHistory scans the glucose pages backwards from the current one and calls fn
+// with each page number and its timed relative records since the specified time.
+func (pump *Pump) sensorHistory(since time.Time, fn func(page int, records []sensorRecord)) {
+	n := pump.CGMCurrentGlucosePage()
+	if pump.Error() != nil {
+		return
+	}
+	pump.scanGlucosePages(n, func(page int, used int, records CGMHistory) bool {
+		if pump.Error() != nil {
+			return false
+		}
+		i := findCGMSince(records, since)
+		fn(page, sensorRecords(records[:i], used))
+		if i < len(records) {
+			pump.logScanStop("sensor history", page, records[i].Time)
+			return false
+		}
+		return pump.Error() == nil
+	})
+}
+
+// sensorISIG returns the ISIG records since the specified time.
+// The page layout is experimental (see above).
+func (pump *Pump) sensorISIG(since time.Time) isigHistory {
+	var results isigHistory
+	pump.sensorHistory(since, func(page int, records []sensorRecord) {
+		if len(records) == 0 {
+			return
+		}
+		data := pump.ISIGPage(page)
+		if pump.Error() != nil {
+			return
+		}
+		results = append(results, isigRecords(data, records)...)
+	})
+	return results
+}
+
+// sensorVcntr returns the vcntr records since the specified time.
+// The page layout is experimental (see above).
+func (pump *Pump) sensorVcntr(since time.Time) vcntrHistory {
+	var results vcntrHistory
+	pump.sensorHistory(since, func(page int, records []sensorRecord) {
+		if len(records) == 0 {
+			return
+		}
+		data := pump.VcntrPage(page)
+		if pump.Error() != nil {
+			return
+		}
+		results = append(results, vcntrRecords(data, records)...)
+	})
+	return results
+}
@@ -0,0 +1,93 @@
+package medtronic
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/thecubic/medtronic/simulator"
+)
+
+// sensorPages returns ISIG and vcntr pages with the given values
+// at the positions of the single-byte records in a glucose page,
+// using the assumed layout.  No captured pages are available,
+// so these tests only check that the decoder follows that layout.
+func sensorPages(glucose []byte, start int, isig []int, vcntr []int) ([]byte, []byte) {
+	isigPage := make([]byte, 2*len(glucose))
+	vcntrPage := make([]byte, len(glucose))
+	for i := range isig {
+		j := start + i
+		isigPage[2*j] = byte(isig[i] >> 8)
+		isigPage[2*j+1] = byte(isig[i])
+		vcntrPage[j] = byte(vcntr[i])
+	}
+	return isigPage, vcntrPage
+}
+
+func TestISIGHistory(t *testing.T) {
+	anchor := parseTime("2016-02-08T20:54")
+	at := func(n int) time.Time { return anchor.Add(-time.Duration(n) * 5 * time.Minute) }
+	config := simulator.DefaultConfig(testPumpID, "523")
+	// Page 0 has only relative records; page 1 ends with a timestamp record.
+	glucose0 := []byte{0x35, 0x40, 0x02}
+	glucose1 := append([]byte{0x50, 0x60}, storedCGMRecord("0814b62810")...)
+	isig0, vcntr0 := sensorPages(glucose0, 0, []int{1000, 2000, 3000}, []int{10, 20, 30})
+	isig1, vcntr1 := sensorPages(glucose1, 0, []int{4000, 5000}, []int{40, 50})
+	config.GlucosePages = map[int][]byte{0: glucose0, 1: glucose1}
+	config.ISIGPages = map[int][]byte{0: isig0, 1: isig1}
+	config.VcntrPages = map[int][]byte{0: vcntr0, 1: vcntr1}
+	pump := simulatedPump(t, config)
+	allISIG := isigHistory{
+		{Time: at(0), Type: CGMGlucose, ISIG: 5000},
+		{Time: at(1), Type: CGMGlucose, ISIG: 4000},
+		{Time: at(2), Type: CGMWeakSignal, ISIG: 3000},
+		{Time: at(3), Type: CGMGlucose, ISIG: 2000},
+		{Time: at(4), Type: CGMGlucose, ISIG: 1000},
+	}
+	allVcntr := vcntrHistory{
+		{Time: at(0), Type: CGMGlucose, Vcntr: 50},
+		{Time: at(1), Type: CGMGlucose, Vcntr: 40},
+		{Time: at(2), Type: CGMWeakSignal, Vcntr: 30},
+		{Time: at(3), Type: CGMGlucose, Vcntr: 20},
+		{Time: at(4), Type: CGMGlucose, Vcntr: 10},
+	}
+	cases := []struct {
+		since time.Time
+		n     int
+	}{
+		{time.Time{}, 5},
+		{at(3), 3},
+		{at(1), 1},
+		{at(0), 0},
+	}
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			isig := pump.sensorISIG(c.since)
+			if pump.Error() != nil {
+				t.Fatal(pump.Error())
+			}
+			if !reflect.DeepEqual(isig, allISIG[:c.n]) && !(len(isig) == 0 && c.n == 0) {
+				t.Errorf("sensorISIG(%v) returned %+v, want %+v", c.since, isig, allISIG[:c.n])
+			}
+			vcntr := pump.sensorVcntr(c.since)
+			if pump.Error() != nil {
+				t.Fatal(pump.Error())
+			}
+			if !reflect.DeepEqual(vcntr, allVcntr[:c.n]) && !(len(vcntr) == 0 && c.n == 0) {
+				t.Errorf("sensorVcntr(%v) returned %+v, want %+v", c.since, vcntr, allVcntr[:c.n])
+			}
+		})
+	}
+	// Decoding a single page does not modify the glucose data.
+	saved := append([]byte(nil), glucose1...)
+	isig, _, err := decodeISIGHistory(isig1, glucose1, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(isig, allISIG[:2]) {
+		t.Errorf("decodeISIGHistory returned %+v, want %+v", isig, allISIG[:2])
+	}
+	if !reflect.DeepEqual(glucose1, saved) {
+		t.Errorf("decodeISIGHistory modified glucose page")
+	}
+}
@@ -170,11 +170,16 @@ func (r *SettingsInfo) UnmarshalJSON(data []byte) error {
 	if err != nil {
 		return err
 	}
-	r.AutoOff, err = time.ParseDuration(rep.AutoOff)
-	if err != nil {
-		return err
+	// Allow these to be omitted, as in a declared configuration.
+	if rep.AutoOff != "" {
+		r.AutoOff, err = time.ParseDuration(rep.AutoOff)
+		if err != nil {
+			return err
+		}
+	}
+	if rep.InsulinAction != "" {
+		r.InsulinAction, err = time.ParseDuration(rep.InsulinAction)
 	}
-	r.InsulinAction, err = time.ParseDuration(rep.InsulinAction)
 	return err
 }
 
@@ -265,6 +270,11 @@ func (r *TempBasalType) UnmarshalJSON(data []byte) error {
 	return err
 }
 
+// MarshalJSON marshals DeliveryType values.
+func (r DeliveryType) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf(`"%v"`, r)), nil
+}
+
 // MarshalJSON marshals Insulin values.
 func (r Insulin) MarshalJSON() ([]byte, error) {
 	return json.Marshal(float64(r) / 1000)
@@ -0,0 +1,39 @@
+package medtronic
+
+import (
+	"log/slog"
+)
+
+// Logger is the interface used for the pump's log messages.
+// Each message is followed by alternating keys and values,
+// such as "cmd", "tries", "rssi", "page", and "family".
+// It is satisfied by *slog.Logger.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// Logger returns the logger used for the pump's messages.
+// Unless SetLogger has been called, this is slog.Default(),
+// which writes to the standard log package until slog.SetDefault is called.
+func (pump *Pump) Logger() Logger {
+	if pump.logger == nil {
+		return slog.Default()
+	}
+	return pump.logger
+}
+
+// SetLogger sets the logger used for the pump's messages.
+// A nil logger restores the default.
+func (pump *Pump) SetLogger(logger Logger) {
+	pump.logger = logger
+}
+
+// logRounding logs a change made to an insulin amount to match the pump's resolution.
+func (pump *Pump) logRounding(kind string, amount Insulin, actual Insulin) {
+	if actual != amount {
+		pump.Logger().Info("rounding "+kind, "from", amount, "to", actual)
+	}
+}
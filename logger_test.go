@@ -0,0 +1,68 @@
+package medtronic
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/thecubic/medtronic/simulator"
+)
+
+func TestLogger(t *testing.T) {
+	pump := simulatedPump(t, simulator.DefaultConfig(testPumpID, "523"))
+	var buf bytes.Buffer
+	pump.SetLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	pump.Model()
+	pump.Bolus(1010)
+	if pump.Error() != nil {
+		t.Fatal(pump.Error())
+	}
+	var messages []map[string]interface{}
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var m map[string]interface{}
+		err := dec.Decode(&m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		messages = append(messages, m)
+	}
+	cases := []struct {
+		msg   string
+		key   string
+		value interface{}
+	}{
+		{"command performed", "cmd", "model"},
+		{"command performed", "tries", 1.0},
+		{"pump model", "family", 23.0},
+		{"rounding bolus", "to", 1.0},
+	}
+	for _, c := range cases {
+		found := false
+		for _, m := range messages {
+			if m["msg"] == c.msg && m[c.key] == c.value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("no %q message with %s = %v in %v", c.msg, c.key, c.value, messages)
+		}
+	}
+}
+
+func TestTreatmentsLogger(t *testing.T) {
+	pump := simulatedPump(t, simulator.DefaultConfig(testPumpID, "523"))
+	var buf bytes.Buffer
+	pump.SetLogger(slog.New(slog.NewJSONHandler(&buf, nil)))
+	pump.Treatments(History{testRecord(Rewind, "10:00", nil)})
+	var m map[string]interface{}
+	err := json.NewDecoder(&buf).Decode(&m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m["msg"] != "missing history record" || m["expected"] != "Prime" {
+		t.Errorf("logged %v, want missing Prime record", m)
+	}
+}
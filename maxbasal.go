@@ -2,7 +2,6 @@ package medtronic
 
 import (
 	"fmt"
-	"log"
 )
 
 // SetMaxBasal sets the pump's maximum basal rate.
@@ -15,11 +14,12 @@ func (pump *Pump) SetMaxBasal(rate Insulin) {
 		pump.SetError(fmt.Errorf("max basal rate (%d) is too large", rate))
 		return
 	}
+	if !pump.allow(GuardRequest{Command: setMaxBasal, Amount: rate}) {
+		return
+	}
 	m := milliUnitsPerStroke(23)
 	strokes := rate / m
 	actual := strokes * m
-	if actual != rate {
-		log.Printf("rounding max basal rate from %v to %v", rate, actual)
-	}
+	pump.logRounding("max basal rate", rate, actual)
 	pump.Execute(setMaxBasal, marshalUint16(uint16(strokes))...)
 }
@@ -2,7 +2,6 @@ package medtronic
 
 import (
 	"fmt"
-	"log"
 )
 
 // SetMaxBolus sets the pump's maximum bolus.
@@ -13,14 +12,12 @@ func (pump *Pump) SetMaxBolus(amount Insulin) {
 	if amount > maxBolus {
 		pump.SetError(fmt.Errorf("bolus amount (%d) is too large", amount))
 	}
-	if pump.Error() != nil {
+	if !pump.allow(GuardRequest{Command: setMaxBolus, Amount: amount}) {
 		return
 	}
 	m := milliUnitsPerStroke(22)
 	strokes := amount / m
 	actual := strokes * m
-	if actual != amount {
-		log.Printf("rounding max bolus from %v to %v", amount, actual)
-	}
+	pump.logRounding("max bolus", amount, actual)
 	pump.Execute(setMaxBolus, uint8(strokes))
 }
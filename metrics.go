@@ -0,0 +1,264 @@
+package medtronic
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Outcome classifies the result of a single packet exchange with the pump.
+type Outcome int
+
+const (
+	// Success means that the expected response was received.
+	Success Outcome = iota
+	// NoResponse means that no packet was received before the timeout.
+	NoResponse
+	// CRCFailure means that the received packet could not be decoded.
+	CRCFailure
+	// Rejected means that the pump responded with a NAK.
+	Rejected
+	// Unexpected means that the response was not the one expected.
+	Unexpected
+	// RadioFailure means that the radio reported an error.
+	RadioFailure
+)
+
+var outcomeName = map[Outcome]string{
+	Success:      "success",
+	NoResponse:   "no_response",
+	CRCFailure:   "crc_failure",
+	Rejected:     "nak",
+	Unexpected:   "unexpected",
+	RadioFailure: "radio_failure",
+}
+
+func (o Outcome) String() string {
+	s, found := outcomeName[o]
+	if !found {
+		return fmt.Sprintf("Outcome(%d)", int(o))
+	}
+	return s
+}
+
+// Attempt describes a single packet exchange with the pump.
+type Attempt struct {
+	// Command is the command being performed.
+	// Exchanges of ACK and NAK packets during a multi-packet command
+	// are attributed to that command.
+	Command Command
+	// Try is 1 for the first attempt, 2 for the first retry, etc.
+	Try       int
+	Outcome   Outcome
+	Latency   time.Duration
+	RSSI      int
+	PumpError PumpError // only if Outcome is Rejected
+}
+
+// Metrics receives a report of each packet exchange with the pump.
+// Implementations must be safe for concurrent use
+// if they are shared by several pumps.
+type Metrics interface {
+	Observe(a Attempt)
+}
+
+// Metrics returns the pump's metrics hook, or nil if none has been set.
+func (pump *Pump) Metrics() Metrics {
+	return pump.metrics
+}
+
+// SetMetrics sets the pump's metrics hook.
+// A nil value disables metrics.
+func (pump *Pump) SetMetrics(m Metrics) {
+	pump.metrics = m
+}
+
+func (pump *Pump) observe(cmd Command, resp Command, try int, outcome Outcome, start time.Time, rssi int) {
+	if pump.metrics == nil {
+		return
+	}
+	if cmd == ack || cmd == nak {
+		cmd = resp
+	}
+	a := Attempt{
+		Command: cmd,
+		Try:     try,
+		Outcome: outcome,
+		Latency: time.Since(start),
+		RSSI:    rssi,
+	}
+	if outcome == Rejected {
+		e, ok := pump.Error().(InvalidCommandError)
+		if ok {
+			a.PumpError = e.PumpError
+		}
+	}
+	pump.metrics.Observe(a)
+}
+
+// LatencyBuckets are the upper bounds of the latency histogram buckets used by Stats.
+var LatencyBuckets = []time.Duration{
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// CommandStats holds the accumulated measurements for one command.
+type CommandStats struct {
+	Attempts    int
+	Retries     int
+	Outcomes    map[Outcome]int
+	NAKs        map[PumpError]int
+	LastRSSI    int
+	LatencySum  time.Duration
+	LatencyHist []int // counts for each of LatencyBuckets, plus one for larger values
+}
+
+// Stats is a Metrics implementation that accumulates per-command
+// counters and latency histograms, suitable for export to Prometheus.
+type Stats struct {
+	mu       sync.Mutex
+	commands map[Command]*CommandStats
+}
+
+// NewStats returns an empty Stats value.
+func NewStats() *Stats {
+	return &Stats{commands: make(map[Command]*CommandStats)}
+}
+
+// Observe records a packet exchange.
+func (s *Stats) Observe(a Attempt) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.commands[a.Command]
+	if c == nil {
+		c = &CommandStats{
+			Outcomes:    make(map[Outcome]int),
+			NAKs:        make(map[PumpError]int),
+			LatencyHist: make([]int, len(LatencyBuckets)+1),
+		}
+		s.commands[a.Command] = c
+	}
+	c.Attempts++
+	if a.Try > 1 {
+		c.Retries++
+	}
+	c.Outcomes[a.Outcome]++
+	if a.Outcome == Rejected {
+		c.NAKs[a.PumpError]++
+	}
+	if a.Outcome == Success {
+		c.LastRSSI = a.RSSI
+	}
+	c.LatencySum += a.Latency
+	i := sort.Search(len(LatencyBuckets), func(i int) bool { return a.Latency <= LatencyBuckets[i] })
+	c.LatencyHist[i]++
+}
+
+// Command returns a copy of the measurements for the given command.
+func (s *Stats) Command(cmd Command) CommandStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.commands[cmd]
+	if c == nil {
+		return CommandStats{}
+	}
+	v := *c
+	v.Outcomes = make(map[Outcome]int, len(c.Outcomes))
+	for k, n := range c.Outcomes {
+		v.Outcomes[k] = n
+	}
+	v.NAKs = make(map[PumpError]int, len(c.NAKs))
+	for k, n := range c.NAKs {
+		v.NAKs[k] = n
+	}
+	v.LatencyHist = append([]int(nil), c.LatencyHist...)
+	return v
+}
+
+// WritePrometheus writes the measurements in the Prometheus text exposition format.
+func (s *Stats) WritePrometheus(w io.Writer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var cmds []Command
+	for cmd := range s.commands {
+		cmds = append(cmds, cmd)
+	}
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i] < cmds[j] })
+	p := &promWriter{w: w}
+	p.header("medtronic_attempts_total", "counter", "Packet exchanges with the pump.")
+	for _, cmd := range cmds {
+		p.sample("medtronic_attempts_total", cmd, "", s.commands[cmd].Attempts)
+	}
+	p.header("medtronic_retries_total", "counter", "Packet exchanges that were retries.")
+	for _, cmd := range cmds {
+		p.sample("medtronic_retries_total", cmd, "", s.commands[cmd].Retries)
+	}
+	p.header("medtronic_outcomes_total", "counter", "Packet exchanges by outcome.")
+	for _, cmd := range cmds {
+		c := s.commands[cmd]
+		for o := Success; o <= RadioFailure; o++ {
+			if c.Outcomes[o] != 0 {
+				p.sample("medtronic_outcomes_total", cmd, fmt.Sprintf(`,outcome=%q`, o), c.Outcomes[o])
+			}
+		}
+	}
+	p.header("medtronic_naks_total", "counter", "NAK responses by pump error code.")
+	for _, cmd := range cmds {
+		c := s.commands[cmd]
+		var codes []PumpError
+		for e := range c.NAKs {
+			codes = append(codes, e)
+		}
+		sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+		for _, e := range codes {
+			p.sample("medtronic_naks_total", cmd, fmt.Sprintf(`,code="%d",error=%q`, e, e), c.NAKs[e])
+		}
+	}
+	p.header("medtronic_last_rssi_dbm", "gauge", "RSSI of the most recent response.")
+	for _, cmd := range cmds {
+		p.sample("medtronic_last_rssi_dbm", cmd, "", s.commands[cmd].LastRSSI)
+	}
+	p.header("medtronic_latency_seconds", "histogram", "Round-trip time of packet exchanges.")
+	for _, cmd := range cmds {
+		c := s.commands[cmd]
+		n := 0
+		for i, b := range LatencyBuckets {
+			n += c.LatencyHist[i]
+			p.sample("medtronic_latency_seconds_bucket", cmd, fmt.Sprintf(`,le="%g"`, b.Seconds()), n)
+		}
+		n += c.LatencyHist[len(LatencyBuckets)]
+		p.sample("medtronic_latency_seconds_bucket", cmd, `,le="+Inf"`, n)
+		p.printf("medtronic_latency_seconds_sum{command=%q} %g\n", cmd, c.LatencySum.Seconds())
+		p.sample("medtronic_latency_seconds_count", cmd, "", n)
+	}
+	return p.err
+}
+
+type promWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (p *promWriter) printf(format string, args ...interface{}) {
+	if p.err != nil {
+		return
+	}
+	_, p.err = fmt.Fprintf(p.w, format, args...)
+}
+
+func (p *promWriter) header(name string, kind string, help string) {
+	p.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func (p *promWriter) sample(name string, cmd Command, labels string, v int) {
+	p.printf("%s{command=%q%s} %d\n", name, cmd, labels, v)
+}
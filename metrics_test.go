@@ -0,0 +1,56 @@
+package medtronic
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/thecubic/medtronic/simulator"
+)
+
+func TestStats(t *testing.T) {
+	config := simulator.DefaultConfig(testPumpID, "523")
+	config.Asleep = true
+	pump := simulatedPump(t, config)
+	stats := NewStats()
+	pump.SetMetrics(stats)
+	pump.Model()
+	if !pump.NoResponse() {
+		t.Fatalf("Model() with sleeping pump returned %v", pump.Error())
+	}
+	pump.SetError(nil)
+	pump.Wakeup()
+	pump.Bolus(Insulin(config.MaxBolus + 1000))
+	if _, ok := pump.Error().(InvalidCommandError); !ok {
+		t.Fatalf("Bolus above max returned %v", pump.Error())
+	}
+	m := stats.Command(model)
+	if m.Outcomes[NoResponse] != 2*pump.Retries() {
+		t.Errorf("model: %d no-response outcomes, want %d", m.Outcomes[NoResponse], 2*pump.Retries())
+	}
+	if m.Outcomes[Success] != 1 || m.LastRSSI != pump.RSSI() {
+		t.Errorf("model: %+v", m)
+	}
+	if m.Retries != 2*(pump.Retries()-1) {
+		t.Errorf("model: %d retries, want %d", m.Retries, 2*(pump.Retries()-1))
+	}
+	b := stats.Command(bolus)
+	if b.NAKs[MaxSettingExceeded] != 1 || b.Outcomes[Rejected] != 1 {
+		t.Errorf("bolus: %+v", b)
+	}
+	var buf bytes.Buffer
+	err := stats.WritePrometheus(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		`medtronic_attempts_total{command="model"} 7`,
+		`medtronic_outcomes_total{command="model",outcome="no_response"} 6`,
+		`medtronic_naks_total{command="bolus",code="9",error="MaxSettingExceeded"} 1`,
+		`medtronic_latency_seconds_count{command="bolus"} 2`,
+	} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("WritePrometheus output does not contain %s:\n%s", s, buf.String())
+		}
+	}
+}
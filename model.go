@@ -1,7 +1,6 @@
 package medtronic
 
 import (
-	"log"
 	"strconv"
 )
 
@@ -36,19 +35,19 @@ func (pump *Pump) cacheFamily(model string) {
 	if pump.family != 0 {
 		return
 	}
-	log.Printf("model %s pump", model)
 	family := -1
 	n, err := strconv.Atoi(model)
 	if err != nil {
-		log.Printf("%v", err)
+		pump.Logger().Warn("cannot parse pump model", "model", model, "err", err)
 	} else if 500 < n && n < 600 {
 		family = n - 500
 	} else if 700 < n && n < 800 {
 		family = n - 700
 	} else {
-		log.Printf("unsupported pump model %d", n)
+		pump.Logger().Warn("unsupported pump model", "model", model)
 	}
 	pump.family = Family(family)
+	pump.Logger().Info("pump model", "model", model, "family", pump.family)
 }
 
 // Family returns 22 for 522/722 pumps, 23 for 523/723 pumps, etc.,
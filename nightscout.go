@@ -1,7 +1,7 @@
 package medtronic
 
 import (
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/ecc1/nightscout"
@@ -21,7 +21,18 @@ var (
 // Treatments converts certain pump history records
 // into records that can be uploaded as Nightscout treatments.
 // History records must be in chronological order.
+// Unexpected record sequences are logged with slog.Default().
 func Treatments(records History) []nightscout.Treatment {
+	return treatments(records, slog.Default())
+}
+
+// Treatments is like the Treatments function,
+// but logs unexpected record sequences with the pump's logger.
+func (pump *Pump) Treatments(records History) []nightscout.Treatment {
+	return treatments(records, pump.Logger())
+}
+
+func treatments(records History, logger Logger) []nightscout.Treatment {
 	var treatments []nightscout.Treatment
 	user := nightscout.Username()
 	for i, r := range records {
@@ -33,14 +44,14 @@ func Treatments(records History) []nightscout.Treatment {
 			CreatedAt: r.Time,
 			EnteredBy: user,
 		}
-		if getRecordInfo(r, r2, &info) {
+		if getRecordInfo(r, r2, &info, logger) {
 			treatments = append(treatments, info)
 		}
 	}
 	return treatments
 }
 
-func getRecordInfo(r HistoryRecord, r2 *HistoryRecord, info *nightscout.Treatment) bool {
+func getRecordInfo(r HistoryRecord, r2 *HistoryRecord, info *nightscout.Treatment, logger Logger) bool {
 	t := r.Type()
 	info.EventType = eventType[t]
 	switch t {
@@ -50,7 +61,7 @@ func getRecordInfo(r HistoryRecord, r2 *HistoryRecord, info *nightscout.Treatmen
 		info.Glucose = &g
 		info.Units = gr.Units.String()
 	case TempBasalRate:
-		return tempBasalInfo(r, r2, info)
+		return tempBasalInfo(r, r2, info, logger)
 	case Bolus:
 		b := r.Info.(BolusRecord)
 		ins := b.Amount.NightscoutInsulin()
@@ -58,7 +69,7 @@ func getRecordInfo(r HistoryRecord, r2 *HistoryRecord, info *nightscout.Treatmen
 		min := int(b.Duration / Duration(time.Minute))
 		info.Duration = &min
 	case Rewind:
-		if !nextEvent(r, r2, Prime) {
+		if !nextEvent(r, r2, Prime, logger) {
 			return false
 		}
 	case ResumePump:
@@ -77,12 +88,12 @@ func getRecordInfo(r HistoryRecord, r2 *HistoryRecord, info *nightscout.Treatmen
 	return true
 }
 
-func tempBasalInfo(r HistoryRecord, r2 *HistoryRecord, info *nightscout.Treatment) bool {
+func tempBasalInfo(r HistoryRecord, r2 *HistoryRecord, info *nightscout.Treatment, logger Logger) bool {
 	tb := r.Info.(TempBasalRecord)
 	if tb.Type != Absolute {
 		return false
 	}
-	if !nextEvent(r, r2, TempBasalDuration) {
+	if !nextEvent(r, r2, TempBasalDuration, logger) {
 		return false
 	}
 	if r2.Info.(Duration) == 0 {
@@ -99,15 +110,13 @@ func tempBasalInfo(r HistoryRecord, r2 *HistoryRecord, info *nightscout.Treatmen
 	return true
 }
 
-func nextEvent(r HistoryRecord, r2 *HistoryRecord, t HistoryRecordType) bool {
+func nextEvent(r HistoryRecord, r2 *HistoryRecord, t HistoryRecordType, logger Logger) bool {
 	if r2 == nil {
-		ts := r.Time.Format(UserTimeLayout)
-		log.Printf("expected %v to be followed by %v at %s", r.Type(), t, ts)
+		logger.Warn("missing history record", "record", r.Type().String(), "expected", t.String(), "time", r.Time.Format(UserTimeLayout))
 		return false
 	}
 	if r2.Type() != t {
-		ts := r.Time.Format(UserTimeLayout)
-		log.Printf("expected %v to be followed by %v at %s but found %v", r.Type(), t, ts, r2.Type())
+		logger.Warn("unexpected history record", "record", r.Type().String(), "expected", t.String(), "found", r2.Type().String(), "time", r.Time.Format(UserTimeLayout))
 		return false
 	}
 	return true
@@ -0,0 +1,342 @@
+package medtronic
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ecc1/nightscout"
+)
+
+// NightscoutProfile represents the therapy settings in a Nightscout profile.
+// Basal rates are in units/hour, carb ratios in grams/unit,
+// and sensitivities and targets in the profile's glucose units.
+type NightscoutProfile struct {
+	Basal      nightscout.Schedule `json:"basal"`
+	CarbRatio  nightscout.Schedule `json:"carbratio"`
+	Sens       nightscout.Schedule `json:"sens"`
+	TargetLow  nightscout.Schedule `json:"target_low"`
+	TargetHigh nightscout.Schedule `json:"target_high"`
+	Units      string              `json:"units"`
+}
+
+// nightscoutProfileRecord represents a Nightscout profile document,
+// which contains a set of named profiles.
+type nightscoutProfileRecord struct {
+	DefaultProfile string                       `json:"defaultProfile"`
+	Store          map[string]NightscoutProfile `json:"store"`
+	Units          string                       `json:"units"`
+}
+
+// DecodeNightscoutProfile decodes the named profile from a Nightscout profile document,
+// or from the first document in an array (as returned by the Nightscout profile API).
+// If name is empty, the document's default profile is used.
+func DecodeNightscoutProfile(data []byte, name string) (NightscoutProfile, error) {
+	var records []nightscoutProfileRecord
+	err := json.Unmarshal(data, &records)
+	if err != nil {
+		var r nightscoutProfileRecord
+		err = json.Unmarshal(data, &r)
+		if err != nil {
+			return NightscoutProfile{}, err
+		}
+		records = append(records, r)
+	}
+	if len(records) == 0 {
+		return NightscoutProfile{}, fmt.Errorf("no Nightscout profile documents")
+	}
+	r := records[0]
+	if name == "" {
+		name = r.DefaultProfile
+	}
+	p, found := r.Store[name]
+	if !found {
+		return NightscoutProfile{}, fmt.Errorf("Nightscout profile %q not found", name)
+	}
+	if p.Units == "" {
+		p.Units = r.Units
+	}
+	return p, nil
+}
+
+// PumpProfile holds the pump schedules that correspond to a Nightscout profile.
+// A nil schedule is left unchanged by SetProfile.
+type PumpProfile struct {
+	BasalRates    BasalRateSchedule
+	CarbRatios    CarbRatioSchedule
+	Sensitivities InsulinSensitivitySchedule
+	Targets       GlucoseTargetSchedule
+}
+
+func nightscoutGlucoseUnits(units string) (GlucoseUnitsType, error) {
+	switch strings.ToLower(units) {
+	case "mg/dl":
+		return MgPerDeciLiter, nil
+	case "mmol", "mmol/l":
+		return MMolPerLiter, nil
+	default:
+		return 0, fmt.Errorf("unknown Nightscout glucose units %q", units)
+	}
+}
+
+// scheduleEntry returns the start time and value of a Nightscout schedule entry.
+// Values may be numbers or strings.
+func scheduleEntry(kind string, v nightscout.TimeValue) (TimeOfDay, float64, error) {
+	t, err := ParseTimeOfDay(v.Time)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: %v", kind, err)
+	}
+	switch x := v.Value.(type) {
+	case float64:
+		return t, x, nil
+	case string:
+		f, err := strconv.ParseFloat(x, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("%s: %v", kind, err)
+		}
+		return t, f, nil
+	default:
+		return 0, 0, fmt.Errorf("%s: invalid value %v at %s", kind, v.Value, v.Time)
+	}
+}
+
+// nightscoutGlucose converts a Nightscout glucose value to the pump's resolution.
+func nightscoutGlucose(f float64, units GlucoseUnitsType) Glucose {
+	if units == MMolPerLiter {
+		// Round to 0.1 mmol/L and convert to μmol/L
+		return Glucose(10*f+0.5) * 100
+	}
+	return Glucose(f + 0.5)
+}
+
+// PumpProfile converts a Nightscout profile into pump schedules,
+// using the given pump family and units.
+// Nightscout carb ratios are in grams, and the profile's glucose units must match the pump's.
+// Values are rounded to the pump's resolution (basal rates as by SetBasalRates),
+// so the result can be compared with the pump's current schedules.
+func (p NightscoutProfile) PumpProfile(family Family, carbUnits CarbUnitsType, glucoseUnits GlucoseUnitsType) (PumpProfile, error) {
+	var pp PumpProfile
+	if carbUnits != Grams {
+		return pp, fmt.Errorf("carb units must be grams")
+	}
+	units, err := nightscoutGlucoseUnits(p.Units)
+	if err != nil {
+		return pp, err
+	}
+	if units != glucoseUnits {
+		return pp, fmt.Errorf("Nightscout profile units (%v) do not match pump units (%v)", units, glucoseUnits)
+	}
+	for _, v := range p.Basal {
+		t, f, err := scheduleEntry("basal", v)
+		if err != nil {
+			return pp, err
+		}
+		r, err := encodeBasalRate("basal", Insulin(1000*f+0.5), family)
+		if err != nil {
+			return pp, err
+		}
+		pp.BasalRates = append(pp.BasalRates, BasalRate{Start: t, Rate: Insulin(r) * milliUnitsPerStroke(23)})
+	}
+	for _, v := range p.CarbRatio {
+		t, f, err := scheduleEntry("carbratio", v)
+		if err != nil {
+			return pp, err
+		}
+		r := Ratio(10*f + 0.5)
+		if family <= 22 {
+			// These pumps store whole grams/unit.
+			r -= r % 10
+		}
+		pp.CarbRatios = append(pp.CarbRatios, CarbRatio{Start: t, Ratio: r, Units: Grams})
+	}
+	for _, v := range p.Sens {
+		t, f, err := scheduleEntry("sens", v)
+		if err != nil {
+			return pp, err
+		}
+		pp.Sensitivities = append(pp.Sensitivities, InsulinSensitivity{
+			Start:       t,
+			Sensitivity: nightscoutGlucose(f, units),
+			Units:       units,
+		})
+	}
+	pp.Targets, err = nightscoutTargets(p.TargetLow, p.TargetHigh, units)
+	return pp, err
+}
+
+// nightscoutTargets combines separate low and high target schedules into a GlucoseTargetSchedule,
+// with an entry at each start time in either one.
+func nightscoutTargets(low nightscout.Schedule, high nightscout.Schedule, units GlucoseUnitsType) (GlucoseTargetSchedule, error) {
+	lows := make(map[TimeOfDay]Glucose)
+	highs := make(map[TimeOfDay]Glucose)
+	var starts []TimeOfDay
+	for _, s := range []struct {
+		kind   string
+		sched  nightscout.Schedule
+		values map[TimeOfDay]Glucose
+	}{
+		{"target_low", low, lows},
+		{"target_high", high, highs},
+	} {
+		for _, v := range s.sched {
+			t, f, err := scheduleEntry(s.kind, v)
+			if err != nil {
+				return nil, err
+			}
+			_, seenLow := lows[t]
+			_, seenHigh := highs[t]
+			if !seenLow && !seenHigh {
+				starts = append(starts, t)
+			}
+			s.values[t] = nightscoutGlucose(f, units)
+		}
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i] < starts[j] })
+	var sched GlucoseTargetSchedule
+	var last GlucoseTarget
+	for _, t := range starts {
+		last.Start = t
+		last.Units = units
+		if g, found := lows[t]; found {
+			last.Low = g
+		}
+		if g, found := highs[t]; found {
+			last.High = g
+		}
+		sched = append(sched, last)
+	}
+	return sched, nil
+}
+
+// ProfileDiff describes a schedule or setting that differs between two pump profiles or backups.
+// Each schedule entry is formatted as a start time followed by its values.
+// ReadOnly settings cannot be changed remotely and must be set on the pump itself.
+type ProfileDiff struct {
+	Name     string
+	Current  []string
+	New      []string
+	ReadOnly bool
+}
+
+func (s BasalRateSchedule) entries() []string {
+	var v []string
+	for _, r := range s {
+		v = append(v, fmt.Sprintf("%v %v", r.Start, r.Rate))
+	}
+	return v
+}
+
+func (s CarbRatioSchedule) entries() []string {
+	var v []string
+	for _, r := range s {
+		v = append(v, fmt.Sprintf("%v %d %v", r.Start, r.Ratio, r.Units))
+	}
+	return v
+}
+
+func (s InsulinSensitivitySchedule) entries() []string {
+	var v []string
+	for _, r := range s {
+		v = append(v, fmt.Sprintf("%v %d %v", r.Start, r.Sensitivity, r.Units))
+	}
+	return v
+}
+
+func (s GlucoseTargetSchedule) entries() []string {
+	var v []string
+	for _, r := range s {
+		v = append(v, fmt.Sprintf("%v %d-%d %v", r.Start, r.Low, r.High, r.Units))
+	}
+	return v
+}
+
+func sameEntries(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// profileSchedules returns the names and formatted entries of a profile's schedules.
+func (p PumpProfile) profileSchedules() []ProfileDiff {
+	return []ProfileDiff{
+		{Name: "basal", New: p.BasalRates.entries()},
+		{Name: "carbratio", New: p.CarbRatios.entries(), ReadOnly: true},
+		{Name: "sens", New: p.Sensitivities.entries(), ReadOnly: true},
+		{Name: "target", New: p.Targets.entries(), ReadOnly: true},
+	}
+}
+
+// Diff returns the schedules in p that differ from those in current.
+// Nil schedules in p are not compared.
+func (p PumpProfile) Diff(current PumpProfile) []ProfileDiff {
+	var diffs []ProfileDiff
+	cur := current.profileSchedules()
+	for i, d := range p.profileSchedules() {
+		if d.New == nil || sameEntries(d.New, cur[i].New) {
+			continue
+		}
+		d.Current = cur[i].New
+		diffs = append(diffs, d)
+	}
+	return diffs
+}
+
+// Changes returns a copy of p with the schedules that do not differ from those in current set to nil.
+func (p PumpProfile) Changes(current PumpProfile) PumpProfile {
+	changed := make(map[string]bool)
+	for _, d := range p.Diff(current) {
+		changed[d.Name] = true
+	}
+	if !changed["basal"] {
+		p.BasalRates = nil
+	}
+	if !changed["carbratio"] {
+		p.CarbRatios = nil
+	}
+	if !changed["sens"] {
+		p.Sensitivities = nil
+	}
+	if !changed["target"] {
+		p.Targets = nil
+	}
+	return p
+}
+
+// Profile returns the pump's standard basal rate schedule and bolus wizard schedules.
+func (pump *Pump) Profile() PumpProfile {
+	var p PumpProfile
+	p.BasalRates = pump.BasalRates()
+	if pump.Error() != nil {
+		return PumpProfile{}
+	}
+	p.CarbRatios = pump.CarbRatios()
+	if pump.Error() != nil {
+		return PumpProfile{}
+	}
+	p.Sensitivities = pump.InsulinSensitivities()
+	if pump.Error() != nil {
+		return PumpProfile{}
+	}
+	p.Targets = pump.GlucoseTargets()
+	if pump.Error() != nil {
+		return PumpProfile{}
+	}
+	return p
+}
+
+// SetProfile sets the pump's standard basal rate schedule from p, if it is not nil.
+// The commands to set the bolus wizard schedules are not known,
+// so they are read-only and the other schedules in p are ignored.
+func (pump *Pump) SetProfile(p PumpProfile) {
+	if p.BasalRates != nil {
+		pump.SetBasalRates(p.BasalRates)
+	}
+}
@@ -0,0 +1,110 @@
+package medtronic
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testNightscoutProfile = `[
+  {
+    "defaultProfile": "Default",
+    "units": "mg/dl",
+    "store": {
+      "Default": {
+        "basal": [{"time": "00:00", "value": "0.8"}, {"time": "06:00", "value": 1.05}, {"time": "12:00", "value": 0.84}],
+        "carbratio": [{"time": "00:00", "value": 15}, {"time": "11:00", "value": "12.5"}],
+        "sens": [{"time": "00:00", "value": 50}],
+        "target_low": [{"time": "00:00", "value": 100}, {"time": "22:00", "value": 110}],
+        "target_high": [{"time": "00:00", "value": 120}, {"time": "07:00", "value": 110}]
+      },
+      "Exercise": {
+        "basal": [{"time": "00:00", "value": 0.5}],
+        "units": "mmol",
+        "sens": [{"time": "00:00", "value": 3.24}]
+      }
+    }
+  }
+]`
+
+func TestNightscoutProfile(t *testing.T) {
+	ns, err := DecodeNightscoutProfile([]byte(testNightscoutProfile), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := ns.PumpProfile(23, Grams, MgPerDeciLiter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := PumpProfile{
+		BasalRates: BasalRateSchedule{
+			{parseTD("00:00"), 800},
+			{parseTD("06:00"), 1050},
+			{parseTD("12:00"), 825},
+		},
+		CarbRatios: CarbRatioSchedule{
+			{parseTD("00:00"), 150, Grams},
+			{parseTD("11:00"), 125, Grams},
+		},
+		Sensitivities: InsulinSensitivitySchedule{
+			{parseTD("00:00"), 50, MgPerDeciLiter},
+		},
+		Targets: GlucoseTargetSchedule{
+			{parseTD("00:00"), 100, 120, MgPerDeciLiter},
+			{parseTD("07:00"), 100, 110, MgPerDeciLiter},
+			{parseTD("22:00"), 110, 110, MgPerDeciLiter},
+		},
+	}
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("PumpProfile() == %+v, want %+v", p, want)
+	}
+	_, err = ns.PumpProfile(23, Grams, MMolPerLiter)
+	if err == nil {
+		t.Errorf("PumpProfile() with mismatched glucose units did not return an error")
+	}
+	ns, err = DecodeNightscoutProfile([]byte(testNightscoutProfile), "Exercise")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err = ns.PumpProfile(23, Grams, MMolPerLiter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Sensitivities[0].Sensitivity != 3200 || p.CarbRatios != nil {
+		t.Errorf("PumpProfile() == %+v", p)
+	}
+	ns, err = DecodeNightscoutProfile([]byte(testNightscoutProfile), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err = ns.PumpProfile(22, Grams, MgPerDeciLiter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.BasalRates[2].Rate != 800 || p.CarbRatios[1].Ratio != 120 {
+		t.Errorf("PumpProfile() == %+v, want values rounded for x22 pumps", p)
+	}
+	_, err = DecodeNightscoutProfile([]byte(testNightscoutProfile), "Missing")
+	if err == nil {
+		t.Errorf("DecodeNightscoutProfile of missing profile did not return an error")
+	}
+}
+
+func TestProfileDiff(t *testing.T) {
+	current := PumpProfile{
+		BasalRates: BasalRateSchedule{{0, 1000}},
+		CarbRatios: CarbRatioSchedule{{0, 100, Grams}},
+	}
+	p := PumpProfile{
+		BasalRates: BasalRateSchedule{{0, 1000}},
+		CarbRatios: CarbRatioSchedule{{0, 120, Grams}},
+	}
+	diffs := p.Diff(current)
+	want := []ProfileDiff{{Name: "carbratio", Current: []string{"00:00 100 Grams"}, New: []string{"00:00 120 Grams"}, ReadOnly: true}}
+	if !reflect.DeepEqual(diffs, want) {
+		t.Errorf("Diff() == %+v, want %+v", diffs, want)
+	}
+	changes := p.Changes(current)
+	if changes.BasalRates != nil || !reflect.DeepEqual(changes.CarbRatios, p.CarbRatios) {
+		t.Errorf("Changes() == %+v", changes)
+	}
+}
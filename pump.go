@@ -1,6 +1,7 @@
 package medtronic
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -8,11 +9,14 @@ import (
 	"time"
 
 	"github.com/ecc1/radio"
+	"github.com/thecubic/medtronic/capture"
 )
 
 const (
 	pumpEnvVar       = "MEDTRONIC_PUMP_ID"
 	freqEnvVar       = "MEDTRONIC_FREQUENCY"
+	captureEnvVar    = "MEDTRONIC_CAPTURE_FILE"
+	replayEnvVar     = "MEDTRONIC_REPLAY_FILE"
 	defaultFrequency = 916600000
 	defaultTimeout   = 500 * time.Millisecond
 	defaultRetries   = 3
@@ -50,38 +54,151 @@ type Pump struct {
 	// 22 for 522/722, 23 for 523/723, etc.
 	family Family
 
+	// Packet header (device type and pump address) and precomputed ACK packet.
+	header    []byte
+	ackPacket []byte
+
 	// Implicit parameters for command execution.
+	ctx     context.Context
 	timeout time.Duration
 	retries int
 	rssi    int
 	err     error
+	logger  Logger
+	metrics Metrics
+	guard   Guard
+
+	// Whether a sensor timestamp may be written to the CGM history.
+	writeCGMTimestamp bool
+}
+
+// Options specifies how to communicate with a pump.
+type Options struct {
+	// PumpID is the pump's 6-digit ID.
+	PumpID string
+
+	// Frequency is the radio frequency in Hertz.
+	// If zero, a default frequency is used.
+	Frequency uint32
+
+	// Radio returns the radio interface to use.
+	// If nil, the radio selected by the build tags is opened.
+	Radio func() radio.Interface
+
+	// Timeout and Retries are the initial values for pump communications.
+	// If zero, default values are used.
+	Timeout time.Duration
+	Retries int
+
+	// Logger receives the pump's log messages.
+	// If nil, slog.Default() is used.
+	Logger Logger
+
+	// Metrics, if not nil, receives a report of each packet exchange.
+	Metrics Metrics
+
+	// Guard, if not nil, checks each state-changing command
+	// before it is sent to the pump.
+	Guard Guard
+
+	// WriteCGMTimestamp allows CGM history functions to write
+	// a sensor timestamp when the current page needs one.
+	WriteCGMTimestamp bool
 }
 
 // Open opens radio communication with a pump.
+// The pump ID and frequency are taken from the MEDTRONIC_PUMP_ID
+// and MEDTRONIC_FREQUENCY environment variables.
+// If MEDTRONIC_CAPTURE_FILE is set, all radio traffic is recorded in that file.
+// If MEDTRONIC_REPLAY_FILE is set, a previously captured session is replayed
+// instead of using the radio.
+// The radio is closed when the program receives an interrupt or termination signal.
 func Open() *Pump {
-	r := radioInterface()
+	id := os.Getenv(pumpEnvVar)
+	if len(id) == 0 {
+		log.Fatalf("%s environment variable is not set", pumpEnvVar)
+	}
+	pump := OpenWith(Options{
+		PumpID:    id,
+		Frequency: getFrequency(),
+		Radio:     openRadio,
+	})
+	if pump.Error() != nil {
+		return pump
+	}
+	go pump.closeWhenSignaled()
+	return pump
+}
+
+// OpenWith opens radio communication with the pump specified by opts.
+// Unlike Open, it does not consult the environment,
+// so several pumps can be used in one program.
+func OpenWith(opts Options) *Pump {
+	if opts.Radio == nil {
+		opts.Radio = radioInterface
+	}
+	if opts.Frequency == 0 {
+		opts.Frequency = defaultFrequency
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = defaultTimeout
+	}
+	if opts.Retries == 0 {
+		opts.Retries = defaultRetries
+	}
+	r := opts.Radio()
 	pump := &Pump{
 		Radio:   r,
-		timeout: defaultTimeout,
-		retries: defaultRetries,
+		ctx:     context.Background(),
+		timeout: opts.Timeout,
+		retries: opts.Retries,
+		logger:  opts.Logger,
+		metrics: opts.Metrics,
+		guard:   opts.Guard,
+
+		writeCGMTimestamp: opts.WriteCGMTimestamp,
 	}
 	if pump.Error() != nil {
-		log.Printf("cannot connect to %s radio on %s", r.Name(), r.Device())
+		pump.Logger().Error("cannot connect to radio", "radio", r.Name(), "device", r.Device(), "err", pump.Error())
 		return pump
 	}
-	log.Printf("connected to %s radio on %s", r.Name(), r.Device())
-	precomputePackets()
-	freq := getFrequency()
-	log.Printf("setting frequency to %s", radio.MegaHertz(freq))
-	r.Init(freq)
-	go pump.closeWhenSignaled()
+	addr, err := DeviceAddress(opts.PumpID)
+	if err != nil {
+		pump.SetError(err)
+		return pump
+	}
+	pump.setAddress(addr)
+	pump.Logger().Info("connected to radio", "radio", r.Name(), "device", r.Device())
+	pump.Logger().Info("setting frequency", "frequency", radio.MegaHertz(opts.Frequency))
+	r.Init(opts.Frequency)
 	return pump
 }
 
+func openRadio() radio.Interface {
+	file := os.Getenv(replayEnvVar)
+	if len(file) != 0 {
+		r, err := capture.Replay(file)
+		if err != nil {
+			log.Fatalf("%s: %v", replayEnvVar, err)
+		}
+		return r
+	}
+	r := radioInterface()
+	file = os.Getenv(captureEnvVar)
+	if len(file) == 0 {
+		return r
+	}
+	rec, err := capture.Record(r, file)
+	if err != nil {
+		log.Fatalf("%s: %v", captureEnvVar, err)
+	}
+	return rec
+}
+
 // Close closes communication with the pump.
 func (pump *Pump) Close() {
 	r := pump.Radio
-	log.Printf("disconnecting %s radio on %s", r.Name(), r.Device())
+	pump.Logger().Info("disconnecting radio", "radio", r.Name(), "device", r.Device())
 	r.Close()
 }
 
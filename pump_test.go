@@ -0,0 +1,286 @@
+package medtronic
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ecc1/radio"
+	"github.com/thecubic/medtronic/capture"
+	"github.com/thecubic/medtronic/simulator"
+)
+
+const testPumpID = "123456"
+
+func simulatedPump(t *testing.T, config simulator.Config) *Pump {
+	log.SetOutput(ioutil.Discard)
+	pump := OpenWith(Options{
+		PumpID: config.ID,
+		Radio:  func() radio.Interface { return simulator.New(config) },
+	})
+	if pump.Error() != nil {
+		t.Fatal(pump.Error())
+	}
+	return pump
+}
+
+func TestSimulatedPump(t *testing.T) {
+	for _, model := range []string{"512", "522", "523", "554"} {
+		t.Run(model, func(t *testing.T) {
+			config := simulator.DefaultConfig(testPumpID, model)
+			config.Asleep = true
+			pump := simulatedPump(t, config)
+			pump.Wakeup()
+			if pump.Model() != model {
+				t.Errorf("Model() == %q, want %q", pump.Model(), model)
+			}
+			if pump.PumpID() != testPumpID {
+				t.Errorf("PumpID() == %q, want %q", pump.PumpID(), testPumpID)
+			}
+			if r := pump.Reservoir(); r != Insulin(config.Reservoir) {
+				t.Errorf("Reservoir() == %v, want %v", r, Insulin(config.Reservoir))
+			}
+			if b := pump.Battery(); b.Voltage != Voltage(config.Battery) {
+				t.Errorf("Battery() == %+v, want %v", b, Voltage(config.Battery))
+			}
+			if d := time.Since(pump.Clock()); d < -time.Second || d > time.Second {
+				t.Errorf("Clock() is off by %v", d)
+			}
+			want := BasalRateSchedule{{0, 1000}}
+			if s := pump.BasalRates(); !reflect.DeepEqual(s, want) {
+				t.Errorf("BasalRates() == %+v, want %+v", s, want)
+			}
+			if s := pump.Settings(); s.MaxBolus != Insulin(config.MaxBolus) || s.MaxBasal != Insulin(config.MaxBasal) {
+				t.Errorf("Settings() == %+v", s)
+			}
+			if pump.Error() != nil {
+				t.Error(pump.Error())
+			}
+		})
+	}
+}
+
+func TestSimulatedHistory(t *testing.T) {
+	pump := simulatedPump(t, simulator.DefaultConfig(testPumpID, "523"))
+	pump.SetAbsoluteTempBasal(30*time.Minute, 1500)
+	pump.Bolus(2500)
+	if pump.Error() != nil {
+		t.Fatal(pump.Error())
+	}
+	tb := pump.TempBasal()
+	if tb.Rate == nil || *tb.Rate != 1500 || tb.Duration != 30*time.Minute {
+		t.Errorf("TempBasal() == %+v", tb)
+	}
+	records := pump.History(time.Now().Add(-time.Hour))
+	if pump.Error() != nil {
+		t.Fatal(pump.Error())
+	}
+	var types []HistoryRecordType
+	for _, r := range records {
+		types = append(types, r.Type())
+	}
+	want := []HistoryRecordType{Bolus, TempBasalDuration, TempBasalRate}
+	if !reflect.DeepEqual(types, want) {
+		t.Errorf("History() returned %v, want %v", types, want)
+	}
+}
+
+func TestSimulatedErrors(t *testing.T) {
+	config := simulator.DefaultConfig(testPumpID, "523")
+	pump := simulatedPump(t, config)
+	pump.Bolus(Insulin(config.MaxBolus + 1000))
+	e, ok := pump.Error().(InvalidCommandError)
+	if !ok || e.PumpError != MaxSettingExceeded {
+		t.Errorf("Bolus above max returned %v, want %v", pump.Error(), MaxSettingExceeded)
+	}
+	pump.SetError(nil)
+	pump.HistoryPage(5)
+	e, ok = pump.Error().(InvalidCommandError)
+	if !ok || e.PumpError != InvalidHistoryPageNumber {
+		t.Errorf("HistoryPage(5) returned %v, want %v", pump.Error(), InvalidHistoryPageNumber)
+	}
+}
+
+type nopCloser struct {
+	*bytes.Buffer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+func TestCaptureReplay(t *testing.T) {
+	pump := simulatedPump(t, simulator.DefaultConfig(testPumpID, "554"))
+	buf := nopCloser{new(bytes.Buffer)}
+	rec := capture.NewRecorder(pump.Radio, buf)
+	pump.Radio = rec
+	pump.SetAbsoluteTempBasal(time.Hour, 800)
+	pump.Suspend(true)
+	since := time.Now().Add(-time.Hour)
+	want := pump.History(since)
+	if pump.Error() != nil {
+		t.Fatal(pump.Error())
+	}
+	if rec.Err() != nil {
+		t.Fatal(rec.Err())
+	}
+	exchanges, err := capture.ReadExchanges(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep := capture.NewReplayer(exchanges)
+	pump = OpenWith(Options{
+		PumpID: testPumpID,
+		Radio:  func() radio.Interface { return rep },
+	})
+	pump.SetAbsoluteTempBasal(time.Hour, 800)
+	pump.Suspend(true)
+	got := pump.History(since)
+	if pump.Error() != nil {
+		t.Fatal(pump.Error())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("replayed History() == %v, want %v", got, want)
+	}
+	if rep.Remaining() != 0 {
+		t.Errorf("%d exchanges were not replayed", rep.Remaining())
+	}
+	pump.Model()
+	if pump.Error() != capture.ErrEndOfCapture {
+		t.Errorf("Model() after end of capture returned %v, want %v", pump.Error(), capture.ErrEndOfCapture)
+	}
+}
+
+func TestMultiplePumps(t *testing.T) {
+	ids := []string{"123456", "654321"}
+	models := []string{"522", "554"}
+	var pumps []*Pump
+	for i, id := range ids {
+		config := simulator.DefaultConfig(id, models[i])
+		config.Reservoir = 10000 * (i + 1)
+		pumps = append(pumps, simulatedPump(t, config))
+	}
+	// A pump only answers packets addressed to it.
+	wrong := OpenWith(Options{
+		PumpID:  ids[1],
+		Radio:   func() radio.Interface { return simulator.New(simulator.DefaultConfig(ids[0], models[0])) },
+		Retries: 1,
+	})
+	wrong.Model()
+	if !wrong.NoResponse() {
+		t.Errorf("Model() with wrong pump ID returned %v, want no response", wrong.Error())
+	}
+	for i, pump := range pumps {
+		if pump.PumpID() != ids[i] {
+			t.Errorf("PumpID() == %q, want %q", pump.PumpID(), ids[i])
+		}
+		if r := pump.Reservoir(); r != Insulin(10000*(i+1)) {
+			t.Errorf("Reservoir() == %v, want %v", r, Insulin(10000*(i+1)))
+		}
+		if pump.Model() != models[i] {
+			t.Errorf("Model() == %q, want %q", pump.Model(), models[i])
+		}
+		if pump.Error() != nil {
+			t.Error(pump.Error())
+		}
+	}
+}
+
+func TestOpenWithInvalidID(t *testing.T) {
+	pump := OpenWith(Options{
+		PumpID: "12345",
+		Radio:  func() radio.Interface { return simulator.New(simulator.DefaultConfig(testPumpID, "523")) },
+	})
+	if pump.Error() == nil {
+		t.Errorf("OpenWith(%q) succeeded", "12345")
+	}
+}
+
+func TestSimulatedBasalPatterns(t *testing.T) {
+	sched := BasalRateSchedule{{0, 500}, {parseTD("12:00"), 750}}
+	standard := BasalRateSchedule{{0, 1000}}
+	cases := []struct {
+		name string
+		set  func(*Pump, BasalRateSchedule)
+		get  func(*Pump) BasalRateSchedule
+	}{
+		{"standard", (*Pump).SetBasalRates, (*Pump).BasalRates},
+		{"patterna", (*Pump).SetBasalPatternA, (*Pump).BasalPatternA},
+		{"patternb", (*Pump).SetBasalPatternB, (*Pump).BasalPatternB},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			pump := simulatedPump(t, simulator.DefaultConfig(testPumpID, "523"))
+			c.set(pump, sched)
+			if s := c.get(pump); !reflect.DeepEqual(s, sched) {
+				t.Errorf("schedule == %+v, want %+v", s, sched)
+			}
+			if c.name != "standard" {
+				// Setting a pattern must not change the standard schedule.
+				if s := pump.BasalRates(); !reflect.DeepEqual(s, standard) {
+					t.Errorf("BasalRates() == %+v, want %+v", s, standard)
+				}
+			}
+			if pump.Error() != nil {
+				t.Error(pump.Error())
+			}
+		})
+	}
+}
+
+func TestSimulatedBackupRestore(t *testing.T) {
+	config := simulator.DefaultConfig("654321", "523")
+	config.InsulinAction = 4 * time.Hour
+	config.MaxBolus = 15000
+	config.MaxBasal = 1500
+	config.SelectedPattern = 1
+	config.BasalRates = []simulator.BasalRate{{Start: 0, Rate: 800}, {Start: 6 * time.Hour, Rate: 1200}}
+	config.BasalPatternA = []simulator.BasalRate{{Start: 0, Rate: 500}}
+	config.BasalPatternB = []simulator.BasalRate{{Start: 0, Rate: 1500}}
+	config.CarbRatios = []simulator.CarbRatio{{Start: 0, Ratio: 150}, {Start: 11 * time.Hour, Ratio: 120}}
+	config.Targets = []simulator.Target{{Start: 0, Low: 90, High: 110}}
+	old := simulatedPump(t, config).Backup()
+	data, err := json.Marshal(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b Backup
+	err = json.Unmarshal(data, &b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(b.Diff(old), []ProfileDiff(nil)) {
+		t.Errorf("unmarshaled backup differs from original: %+v", b.Diff(old))
+	}
+	pump := simulatedPump(t, simulator.DefaultConfig(testPumpID, "523"))
+	dryRun := pump.Restore(b, true)
+	if len(dryRun) == 0 {
+		t.Fatalf("Restore(dry run) found no differences")
+	}
+	if s := pump.BasalRates(); !reflect.DeepEqual(s, BasalRateSchedule{{0, 1000}}) {
+		t.Errorf("Restore(dry run) changed basal rates to %+v", s)
+	}
+	diffs := pump.Restore(b, false)
+	if pump.Error() != nil {
+		t.Fatal(pump.Error())
+	}
+	if !reflect.DeepEqual(diffs, dryRun) {
+		t.Errorf("Restore() == %+v, want %+v", diffs, dryRun)
+	}
+	remaining := b.Diff(pump.Backup())
+	want := []ProfileDiff{
+		{Name: "insulinaction", Current: []string{"3h0m0s"}, New: []string{"4h0m0s"}, ReadOnly: true},
+		{Name: "carbratio", Current: []string{"00:00 100 Grams"}, New: []string{"00:00 150 Grams", "11:00 120 Grams"}, ReadOnly: true},
+		{Name: "target", Current: []string{"00:00 100-120 mg/dL"}, New: []string{"00:00 90-110 mg/dL"}, ReadOnly: true},
+	}
+	if !reflect.DeepEqual(remaining, want) {
+		t.Errorf("differences after Restore() == %+v, want %+v", remaining, want)
+	}
+	if pump.Error() != nil {
+		t.Error(pump.Error())
+	}
+}
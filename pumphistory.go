@@ -1,7 +1,6 @@
 package medtronic
 
 import (
-	"log"
 	"time"
 )
 
@@ -9,6 +8,13 @@ import (
 // Note that the results may include records with a zero timestamp or
 // an earlier timestamp than the cutoff (in the case of DailyTotal records).
 func (pump *Pump) History(since time.Time) History {
+	return pump.history(since, nil)
+}
+
+// history returns the history records since the specified time,
+// calling correct (if it is not nil) on the records from each page
+// before comparing them with the cutoff.
+func (pump *Pump) history(since time.Time, correct func(History)) History {
 	count := pump.HistoryPageCount()
 	if pump.Error() != nil {
 		return nil
@@ -21,9 +27,13 @@ func (pump *Pump) History(since time.Time) History {
 		if err != nil {
 			pump.SetError(err)
 		}
+		if correct != nil {
+			correct(records)
+		}
 		i := findSince(records, since)
 		results = append(results, records[:i]...)
 		if i < len(records) {
+			pump.logScanStop("pump history", page, records[i].Time)
 			break
 		}
 	}
@@ -44,10 +54,14 @@ func findSince(records History, cutoff time.Time) int {
 		default:
 			t := r.Time
 			if !t.IsZero() && !t.After(cutoff) {
-				log.Printf("stopping pump history scan at %s", t.Format(UserTimeLayout))
 				return i
 			}
 		}
 	}
 	return len(records)
 }
+
+// logScanStop logs the page and time at which a history scan stopped.
+func (pump *Pump) logScanStop(kind string, page int, t time.Time) {
+	pump.Logger().Info("stopping "+kind+" scan", "page", page, "time", t.Format(UserTimeLayout))
+}
@@ -0,0 +1,165 @@
+package medtronic
+
+import (
+	"time"
+)
+
+// EXPERIMENTAL: raw glucose values depend on the assumed ISIG page layout
+// (see isig.go) and on the scales below, neither of which has been confirmed
+// by a protocol reference or by comparing with a pump's own readings.
+// Until they are, raw glucose values are not exported or uploaded to Nightscout.
+
+const (
+	// ISIG values are assumed to be reported in units of 0.01 nA.
+	isigScale = 100.0
+
+	// Calibration factors are assumed to be reported in units of 0.001 mg/dL per nA,
+	// which gives factors in the usual range of a few mg/dL per nA
+	// for the values reported by CalibrationFactor.
+	calFactorScale = 1000.0
+
+	// A meter BG is used as a calibration only if the pump
+	// did not record a calibration factor within this interval.
+	calFactorDelay = 15 * time.Minute
+
+	// Filtered values are averaged over readings within this interval.
+	filterWindow = 15 * time.Minute
+)
+
+// nanoAmps returns the sensor current in nA.
+func (r isigRecord) nanoAmps() float64 {
+	return float64(r.ISIG) / isigScale
+}
+
+// calibration represents a sensor calibration factor
+// and the time from which it is in effect.
+type calibration struct {
+	Time   time.Time
+	Factor float64 // mg/dL per nA
+}
+
+// calFactorValue converts a calibration factor as reported by the pump to mg/dL per nA.
+func calFactorValue(v int) float64 {
+	return float64(v) / calFactorScale
+}
+
+// calibrations returns the calibrations in the CGM history, most recent first.
+// Calibration factors recorded by the pump are used directly.
+// A meter BG for which the pump did not record a calibration factor
+// is converted to a factor using the ISIG reading at that time, if available.
+// The records must be in reverse chronological order, as returned by CGMHistory.
+func calibrations(records CGMHistory, isig isigHistory) []calibration {
+	var cals []calibration
+	var lastFactor time.Time
+	for _, r := range records {
+		switch r.Type {
+		case CGMCalFactor:
+			cals = append(cals, calibration{Time: r.Time, Factor: r.CalFactor})
+			lastFactor = r.Time
+		case CGMCalBGForGH:
+			if !lastFactor.IsZero() && lastFactor.Sub(r.Time) <= calFactorDelay {
+				continue
+			}
+			i := findISIG(isig, r.Time)
+			if i < 0 || isig[i].ISIG == 0 {
+				continue
+			}
+			f := float64(r.Glucose) / isig[i].nanoAmps()
+			cals = append(cals, calibration{Time: r.Time, Factor: f})
+		}
+	}
+	return cals
+}
+
+// findISIG returns the index of the most recent ISIG record
+// within 5 minutes before or at the given time, or -1 if there is none.
+func findISIG(isig isigHistory, t time.Time) int {
+	for i, r := range isig {
+		if r.Time.After(t) {
+			continue
+		}
+		if t.Sub(r.Time) <= 5*time.Minute {
+			return i
+		}
+		break
+	}
+	return -1
+}
+
+// rawGlucose represents glucose values recomputed from a sensor current measurement.
+type rawGlucose struct {
+	Time       time.Time
+	ISIG       int
+	Factor     float64 // calibration factor used
+	Unfiltered float64 // mg/dL
+	Filtered   float64 // mg/dL, averaged over recent readings
+}
+
+// rawGlucoseHistory represents a sequence of raw glucose values.
+type rawGlucoseHistory []rawGlucose
+
+// computeRawGlucose recomputes glucose values from ISIG records,
+// using the most recent calibration in effect for each reading.
+// Readings that precede all the calibrations use the given default factor in mg/dL per nA
+// (typically the pump's current CalibrationFactor divided by 1000), or are omitted if it is 0.
+// The ISIG records and calibrations must be in reverse chronological order,
+// and the results are in the same order.
+func computeRawGlucose(isig isigHistory, cals []calibration, factor float64) rawGlucoseHistory {
+	var results rawGlucoseHistory
+	j := 0
+	for _, r := range isig {
+		if r.ISIG == 0 {
+			continue
+		}
+		for j < len(cals) && cals[j].Time.After(r.Time) {
+			j++
+		}
+		f := factor
+		if j < len(cals) {
+			f = cals[j].Factor
+		}
+		if f == 0 {
+			continue
+		}
+		results = append(results, rawGlucose{
+			Time:       r.Time,
+			ISIG:       r.ISIG,
+			Factor:     f,
+			Unfiltered: f * r.nanoAmps(),
+		})
+	}
+	results.filter()
+	return results
+}
+
+// filter sets each Filtered value to the average of the
+// unfiltered values within the filter window ending at that reading.
+func (h rawGlucoseHistory) filter() {
+	for i := range h {
+		sum := 0.0
+		n := 0
+		for j := i; j < len(h) && h[i].Time.Sub(h[j].Time) < filterWindow; j++ {
+			sum += h[j].Unfiltered
+			n++
+		}
+		h[i].Filtered = sum / float64(n)
+	}
+}
+
+// sensorRawGlucose returns glucose values recomputed from the ISIG history since the specified time,
+// using the calibrations in the CGM history and the pump's current calibration factor.
+func (pump *Pump) sensorRawGlucose(since time.Time) rawGlucoseHistory {
+	isig := pump.sensorISIG(since)
+	if pump.Error() != nil {
+		return nil
+	}
+	records := pump.CGMHistory(since)
+	if pump.Error() != nil {
+		return nil
+	}
+	factor := calFactorValue(pump.CalibrationFactor())
+	if pump.Error() != nil {
+		return nil
+	}
+	return computeRawGlucose(isig, calibrations(records, isig), factor)
+}
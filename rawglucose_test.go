@@ -0,0 +1,59 @@
+package medtronic
+
+import (
+	"math"
+	"testing"
+)
+
+func TestComputeRawGlucose(t *testing.T) {
+	records := CGMHistory{
+		{Type: CGMGlucose, Time: parseTime("2015-05-19T15:45"), Glucose: 230},
+		{Type: CGMCalFactor, Time: parseTime("2015-05-19T15:39"), Data: parseBytes("0f4f67130f128c"), CalFactor: 4.748},
+		{Type: CGMGlucose, Time: parseTime("2015-05-19T15:30"), Glucose: 124},
+		// Superseded by the calibration factor.
+		{Type: CGMCalBGForGH, Time: parseTime("2015-05-19T15:27"), Data: parseBytes("0e4f5b138fa0"), Glucose: 160},
+		{Type: CGMCalBGForGH, Time: parseTime("2015-05-19T15:22"), Data: parseBytes("0e4f5b138fa0"), Glucose: 160},
+		{Type: CGMGlucose, Time: parseTime("2015-05-19T15:20"), Glucose: 98},
+	}
+	isig := isigHistory{
+		{Time: parseTime("2015-05-19T15:45"), Type: CGMGlucose, ISIG: 5000},
+		{Time: parseTime("2015-05-19T15:30"), Type: CGMGlucose, ISIG: 3000},
+		{Time: parseTime("2015-05-19T15:25"), Type: CGMWeakSignal, ISIG: 4000},
+		{Time: parseTime("2015-05-19T15:20"), Type: CGMGlucose, ISIG: 2000},
+	}
+	cals := calibrations(records, isig)
+	wantCals := []calibration{
+		{Time: parseTime("2015-05-19T15:39"), Factor: 4.748},
+		{Time: parseTime("2015-05-19T15:22"), Factor: 8.0},
+	}
+	if len(cals) != len(wantCals) {
+		t.Fatalf("calibrations returned %+v, want %+v", cals, wantCals)
+	}
+	for i, c := range cals {
+		if !c.Time.Equal(wantCals[i].Time) || !closeTo(c.Factor, wantCals[i].Factor) {
+			t.Errorf("calibration %d = %+v, want %+v", i, c, wantCals[i])
+		}
+	}
+	raw := computeRawGlucose(isig, cals, 5.0)
+	cases := []struct {
+		unfiltered float64
+		filtered   float64
+	}{
+		{237.4, 237.4},
+		{240, 220},
+		{320, 210},
+		{100, 100},
+	}
+	if len(raw) != len(cases) {
+		t.Fatalf("computeRawGlucose returned %d values, want %d", len(raw), len(cases))
+	}
+	for i, c := range cases {
+		if !closeTo(raw[i].Unfiltered, c.unfiltered) || !closeTo(raw[i].Filtered, c.filtered) {
+			t.Errorf("computeRawGlucose[%d] = %+v, want unfiltered %g, filtered %g", i, raw[i], c.unfiltered, c.filtered)
+		}
+	}
+}
+
+func closeTo(x, y float64) bool {
+	return math.Abs(x-y) < 0.001
+}
@@ -0,0 +1,296 @@
+package medtronic
+
+import (
+	"time"
+)
+
+// The methods in this file provide an alternative to the pump's error state.
+// Each one clears the error state, performs the corresponding operation,
+// and returns its result together with any error, leaving the error state clear.
+// Read methods query the pump; Write methods change its state.
+// Errors are returned unchanged (NoResponseError, InvalidCommandError,
+// BadResponseError, etc.), so they can be examined with errors.As.
+
+// try performs an operation with a clear error state and returns its error.
+func (pump *Pump) try(op func()) error {
+	pump.SetError(nil)
+	op()
+	err := pump.Error()
+	pump.SetError(nil)
+	return err
+}
+
+// ExecuteCommand sends a command and parameters to the pump and returns its response.
+func (pump *Pump) ExecuteCommand(cmd Command, params ...byte) ([]byte, error) {
+	var v []byte
+	err := pump.try(func() { v = pump.Execute(cmd, params...) })
+	return v, err
+}
+
+// ReadModel returns the pump's model number.
+func (pump *Pump) ReadModel() (string, error) {
+	var v string
+	err := pump.try(func() { v = pump.Model() })
+	return v, err
+}
+
+// ReadFamily returns the pump's family.
+func (pump *Pump) ReadFamily() (Family, error) {
+	var v Family
+	err := pump.try(func() { v = pump.Family() })
+	return v, err
+}
+
+// ReadPumpID returns the pump's ID.
+func (pump *Pump) ReadPumpID() (string, error) {
+	var v string
+	err := pump.try(func() { v = pump.PumpID() })
+	return v, err
+}
+
+// ReadFirmwareVersion returns the pump's firmware version.
+func (pump *Pump) ReadFirmwareVersion() (string, error) {
+	var v string
+	err := pump.try(func() { v = pump.FirmwareVersion() })
+	return v, err
+}
+
+// ReadClock returns the time according to the pump's clock.
+func (pump *Pump) ReadClock() (time.Time, error) {
+	var v time.Time
+	err := pump.try(func() { v = pump.Clock() })
+	return v, err
+}
+
+// ReadBattery returns the pump's battery information.
+func (pump *Pump) ReadBattery() (BatteryInfo, error) {
+	var v BatteryInfo
+	err := pump.try(func() { v = pump.Battery() })
+	return v, err
+}
+
+// ReadReservoir returns the amount of insulin remaining.
+func (pump *Pump) ReadReservoir() (Insulin, error) {
+	var v Insulin
+	err := pump.try(func() { v = pump.Reservoir() })
+	return v, err
+}
+
+// ReadStatus returns the pump's status.
+func (pump *Pump) ReadStatus() (StatusInfo, error) {
+	var v StatusInfo
+	err := pump.try(func() { v = pump.Status() })
+	return v, err
+}
+
+// ReadSettings returns the pump's settings.
+func (pump *Pump) ReadSettings() (SettingsInfo, error) {
+	var v SettingsInfo
+	err := pump.try(func() { v = pump.Settings() })
+	return v, err
+}
+
+// ReadTempBasal returns the pump's current temporary basal setting.
+func (pump *Pump) ReadTempBasal() (TempBasalInfo, error) {
+	var v TempBasalInfo
+	err := pump.try(func() { v = pump.TempBasal() })
+	return v, err
+}
+
+// ReadBasalRates returns the pump's basal rate schedule.
+func (pump *Pump) ReadBasalRates() (BasalRateSchedule, error) {
+	var v BasalRateSchedule
+	err := pump.try(func() { v = pump.BasalRates() })
+	return v, err
+}
+
+// ReadBasalPatternA returns the pump's basal pattern A.
+func (pump *Pump) ReadBasalPatternA() (BasalRateSchedule, error) {
+	var v BasalRateSchedule
+	err := pump.try(func() { v = pump.BasalPatternA() })
+	return v, err
+}
+
+// ReadBasalPatternB returns the pump's basal pattern B.
+func (pump *Pump) ReadBasalPatternB() (BasalRateSchedule, error) {
+	var v BasalRateSchedule
+	err := pump.try(func() { v = pump.BasalPatternB() })
+	return v, err
+}
+
+// ReadCarbRatios returns the pump's carb ratio schedule.
+func (pump *Pump) ReadCarbRatios() (CarbRatioSchedule, error) {
+	var v CarbRatioSchedule
+	err := pump.try(func() { v = pump.CarbRatios() })
+	return v, err
+}
+
+// ReadCarbUnits returns the pump's carb units.
+func (pump *Pump) ReadCarbUnits() (CarbUnitsType, error) {
+	var v CarbUnitsType
+	err := pump.try(func() { v = pump.CarbUnits() })
+	return v, err
+}
+
+// ReadGlucoseUnits returns the pump's glucose units.
+func (pump *Pump) ReadGlucoseUnits() (GlucoseUnitsType, error) {
+	var v GlucoseUnitsType
+	err := pump.try(func() { v = pump.GlucoseUnits() })
+	return v, err
+}
+
+// ReadInsulinSensitivities returns the pump's insulin sensitivity schedule.
+func (pump *Pump) ReadInsulinSensitivities() (InsulinSensitivitySchedule, error) {
+	var v InsulinSensitivitySchedule
+	err := pump.try(func() { v = pump.InsulinSensitivities() })
+	return v, err
+}
+
+// ReadGlucoseTargets returns the pump's glucose target schedule.
+func (pump *Pump) ReadGlucoseTargets() (GlucoseTargetSchedule, error) {
+	var v GlucoseTargetSchedule
+	err := pump.try(func() { v = pump.GlucoseTargets() })
+	return v, err
+}
+
+// ReadBackup returns all the pump's settings.
+func (pump *Pump) ReadBackup() (Backup, error) {
+	var v Backup
+	err := pump.try(func() { v = pump.Backup() })
+	return v, err
+}
+
+// ReadHistoryPageCount returns the number of pump history pages.
+func (pump *Pump) ReadHistoryPageCount() (int, error) {
+	var v int
+	err := pump.try(func() { v = pump.HistoryPageCount() })
+	return v, err
+}
+
+// ReadHistoryPage downloads the given history page.
+func (pump *Pump) ReadHistoryPage(page int) ([]byte, error) {
+	var v []byte
+	err := pump.try(func() { v = pump.HistoryPage(page) })
+	return v, err
+}
+
+// ReadHistory returns the history records since the specified time.
+// Records retrieved before an error occurred are returned along with it.
+func (pump *Pump) ReadHistory(since time.Time) (History, error) {
+	var v History
+	err := pump.try(func() { v = pump.History(since) })
+	return v, err
+}
+
+// ReadCGMHistory returns the CGM records since the specified time.
+// Records retrieved before an error occurred are returned along with it.
+func (pump *Pump) ReadCGMHistory(since time.Time) (CGMHistory, error) {
+	var v CGMHistory
+	err := pump.try(func() { v = pump.CGMHistory(since) })
+	return v, err
+}
+
+// ReadGlucosePage downloads the given glucose page.
+func (pump *Pump) ReadGlucosePage(page int) ([]byte, error) {
+	var v []byte
+	err := pump.try(func() { v = pump.GlucosePage(page) })
+	return v, err
+}
+
+// ReadISIGPage downloads the given ISIG page.
+func (pump *Pump) ReadISIGPage(page int) ([]byte, error) {
+	var v []byte
+	err := pump.try(func() { v = pump.ISIGPage(page) })
+	return v, err
+}
+
+// ReadVcntrPage downloads the given vcntr page.
+func (pump *Pump) ReadVcntrPage(page int) ([]byte, error) {
+	var v []byte
+	err := pump.try(func() { v = pump.VcntrPage(page) })
+	return v, err
+}
+
+// ReadCalibrationFactor returns the CGM calibration factor.
+func (pump *Pump) ReadCalibrationFactor() (int, error) {
+	var v int
+	err := pump.try(func() { v = pump.CalibrationFactor() })
+	return v, err
+}
+
+// ReadCGMCurrentGlucosePage returns the current CGM glucose page number.
+func (pump *Pump) ReadCGMCurrentGlucosePage() (int, error) {
+	var v int
+	err := pump.try(func() { v = pump.CGMCurrentGlucosePage() })
+	return v, err
+}
+
+// WriteWakeup wakes up the pump.
+func (pump *Pump) WriteWakeup() error {
+	return pump.try(pump.Wakeup)
+}
+
+// WriteClock sets the pump's clock to the given time.
+func (pump *Pump) WriteClock(t time.Time) error {
+	return pump.try(func() { pump.SetClock(t) })
+}
+
+// WriteMaxBasal sets the pump's maximum basal rate.
+func (pump *Pump) WriteMaxBasal(rate Insulin) error {
+	return pump.try(func() { pump.SetMaxBasal(rate) })
+}
+
+// WriteMaxBolus sets the pump's maximum bolus.
+func (pump *Pump) WriteMaxBolus(amount Insulin) error {
+	return pump.try(func() { pump.SetMaxBolus(amount) })
+}
+
+// WriteBasalRates sets the pump's basal rate schedule.
+func (pump *Pump) WriteBasalRates(s BasalRateSchedule) error {
+	return pump.try(func() { pump.SetBasalRates(s) })
+}
+
+// WriteBasalPatternA sets the pump's basal pattern A.
+func (pump *Pump) WriteBasalPatternA(s BasalRateSchedule) error {
+	return pump.try(func() { pump.SetBasalPatternA(s) })
+}
+
+// WriteBasalPatternB sets the pump's basal pattern B.
+func (pump *Pump) WriteBasalPatternB(s BasalRateSchedule) error {
+	return pump.try(func() { pump.SetBasalPatternB(s) })
+}
+
+// WriteSelectBasalPattern selects the pump's basal pattern.
+func (pump *Pump) WriteSelectBasalPattern(pattern int) error {
+	return pump.try(func() { pump.SelectBasalPattern(pattern) })
+}
+
+// WriteAbsoluteTempBasal sets a temporary basal with the given absolute rate and duration.
+func (pump *Pump) WriteAbsoluteTempBasal(duration time.Duration, rate Insulin) error {
+	return pump.try(func() { pump.SetAbsoluteTempBasal(duration, rate) })
+}
+
+// WritePercentTempBasal sets a temporary basal with the given percent rate and duration.
+func (pump *Pump) WritePercentTempBasal(duration time.Duration, percent int) error {
+	return pump.try(func() { pump.SetPercentTempBasal(duration, percent) })
+}
+
+// WriteSuspend suspends or resumes the pump.
+func (pump *Pump) WriteSuspend(yes bool) error {
+	return pump.try(func() { pump.Suspend(yes) })
+}
+
+// WriteBolus delivers the given amount of insulin as a bolus.
+func (pump *Pump) WriteBolus(amount Insulin) error {
+	return pump.try(func() { pump.Bolus(amount) })
+}
+
+// WriteButton sends the button-press to the pump.
+func (pump *Pump) WriteButton(b PumpButton) error {
+	return pump.try(func() { pump.Button(b) })
+}
+
+// WriteCGMTimestamp writes a new sensor timestamp to the CGM history.
+func (pump *Pump) WriteCGMTimestamp() error {
+	return pump.try(pump.CGMWriteTimestamp)
+}
@@ -0,0 +1,47 @@
+package medtronic
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/thecubic/medtronic/simulator"
+)
+
+func TestReadReservoir(t *testing.T) {
+	config := simulator.DefaultConfig(testPumpID, "522")
+	pump := simulatedPump(t, config)
+	// A stale error state must not prevent the operation.
+	pump.SetError(fmt.Errorf("stale error"))
+	r, err := pump.ReadReservoir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r != Insulin(config.Reservoir) {
+		t.Errorf("ReadReservoir() == %v, want %v", r, Insulin(config.Reservoir))
+	}
+	if pump.Error() != nil {
+		t.Errorf("ReadReservoir left error state %v", pump.Error())
+	}
+}
+
+func TestWriteErrors(t *testing.T) {
+	config := simulator.DefaultConfig(testPumpID, "523")
+	pump := simulatedPump(t, config)
+	err := pump.WriteBolus(Insulin(config.MaxBolus + 100))
+	var e InvalidCommandError
+	if !errors.As(err, &e) || e.PumpError != MaxSettingExceeded {
+		t.Errorf("WriteBolus above max returned %v, want %v", err, MaxSettingExceeded)
+	}
+	if pump.Error() != nil {
+		t.Errorf("WriteBolus left error state %v", pump.Error())
+	}
+	config.Asleep = true
+	pump = simulatedPump(t, config)
+	err = pump.WriteAbsoluteTempBasal(30*time.Minute, 1000)
+	var nr NoResponseError
+	if !errors.As(err, &nr) {
+		t.Errorf("WriteAbsoluteTempBasal to sleeping pump returned %v, want NoResponseError", err)
+	}
+}
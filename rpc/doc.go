@@ -0,0 +1,11 @@
+// Package rpc provides a gRPC service for controlling a pump,
+// defined in medtronic.proto.
+//
+// The message and service code in medtronic.pb.go and medtronic_grpc.pb.go
+// is generated with protoc (requires protoc-gen-go and protoc-gen-go-grpc)
+// and should be regenerated whenever medtronic.proto changes:
+//
+//	go generate github.com/thecubic/medtronic/rpc
+package rpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative medtronic.proto
@@ -0,0 +1,1431 @@
+// Protocol buffer definitions for controlling a Medtronic pump
+// through a pump session (see medtronic.Session).
+//
+// Insulin amounts and rates are in milliUnits (per hour, for rates),
+// glucose values are in mg/dL or μmol/L according to the units field,
+// and times of day are offsets from midnight.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        v5.29.3
+// source: medtronic.proto
+
+package rpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type GlucoseUnits int32
+
+const (
+	GlucoseUnits_GLUCOSE_UNITS_UNSPECIFIED GlucoseUnits = 0
+	GlucoseUnits_MG_PER_DECI_LITER         GlucoseUnits = 1
+	GlucoseUnits_MMOL_PER_LITER            GlucoseUnits = 2
+)
+
+// Enum value maps for GlucoseUnits.
+var (
+	GlucoseUnits_name = map[int32]string{
+		0: "GLUCOSE_UNITS_UNSPECIFIED",
+		1: "MG_PER_DECI_LITER",
+		2: "MMOL_PER_LITER",
+	}
+	GlucoseUnits_value = map[string]int32{
+		"GLUCOSE_UNITS_UNSPECIFIED": 0,
+		"MG_PER_DECI_LITER":         1,
+		"MMOL_PER_LITER":            2,
+	}
+)
+
+func (x GlucoseUnits) Enum() *GlucoseUnits {
+	p := new(GlucoseUnits)
+	*p = x
+	return p
+}
+
+func (x GlucoseUnits) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (GlucoseUnits) Descriptor() protoreflect.EnumDescriptor {
+	return file_medtronic_proto_enumTypes[0].Descriptor()
+}
+
+func (GlucoseUnits) Type() protoreflect.EnumType {
+	return &file_medtronic_proto_enumTypes[0]
+}
+
+func (x GlucoseUnits) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use GlucoseUnits.Descriptor instead.
+func (GlucoseUnits) EnumDescriptor() ([]byte, []int) {
+	return file_medtronic_proto_rawDescGZIP(), []int{0}
+}
+
+type CarbUnits int32
+
+const (
+	CarbUnits_CARB_UNITS_UNSPECIFIED CarbUnits = 0
+	CarbUnits_GRAMS                  CarbUnits = 1
+	CarbUnits_EXCHANGES              CarbUnits = 2
+)
+
+// Enum value maps for CarbUnits.
+var (
+	CarbUnits_name = map[int32]string{
+		0: "CARB_UNITS_UNSPECIFIED",
+		1: "GRAMS",
+		2: "EXCHANGES",
+	}
+	CarbUnits_value = map[string]int32{
+		"CARB_UNITS_UNSPECIFIED": 0,
+		"GRAMS":                  1,
+		"EXCHANGES":              2,
+	}
+)
+
+func (x CarbUnits) Enum() *CarbUnits {
+	p := new(CarbUnits)
+	*p = x
+	return p
+}
+
+func (x CarbUnits) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CarbUnits) Descriptor() protoreflect.EnumDescriptor {
+	return file_medtronic_proto_enumTypes[1].Descriptor()
+}
+
+func (CarbUnits) Type() protoreflect.EnumType {
+	return &file_medtronic_proto_enumTypes[1]
+}
+
+func (x CarbUnits) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CarbUnits.Descriptor instead.
+func (CarbUnits) EnumDescriptor() ([]byte, []int) {
+	return file_medtronic_proto_rawDescGZIP(), []int{1}
+}
+
+type TempBasalType int32
+
+const (
+	TempBasalType_ABSOLUTE TempBasalType = 0
+	TempBasalType_PERCENT  TempBasalType = 1
+)
+
+// Enum value maps for TempBasalType.
+var (
+	TempBasalType_name = map[int32]string{
+		0: "ABSOLUTE",
+		1: "PERCENT",
+	}
+	TempBasalType_value = map[string]int32{
+		"ABSOLUTE": 0,
+		"PERCENT":  1,
+	}
+)
+
+func (x TempBasalType) Enum() *TempBasalType {
+	p := new(TempBasalType)
+	*p = x
+	return p
+}
+
+func (x TempBasalType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TempBasalType) Descriptor() protoreflect.EnumDescriptor {
+	return file_medtronic_proto_enumTypes[2].Descriptor()
+}
+
+func (TempBasalType) Type() protoreflect.EnumType {
+	return &file_medtronic_proto_enumTypes[2]
+}
+
+func (x TempBasalType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TempBasalType.Descriptor instead.
+func (TempBasalType) EnumDescriptor() ([]byte, []int) {
+	return file_medtronic_proto_rawDescGZIP(), []int{2}
+}
+
+type Insulin struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MilliUnits    int32                  `protobuf:"varint,1,opt,name=milli_units,json=milliUnits,proto3" json:"milli_units,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Insulin) Reset() {
+	*x = Insulin{}
+	mi := &file_medtronic_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Insulin) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Insulin) ProtoMessage() {}
+
+func (x *Insulin) ProtoReflect() protoreflect.Message {
+	mi := &file_medtronic_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Insulin.ProtoReflect.Descriptor instead.
+func (*Insulin) Descriptor() ([]byte, []int) {
+	return file_medtronic_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Insulin) GetMilliUnits() int32 {
+	if x != nil {
+		return x.MilliUnits
+	}
+	return 0
+}
+
+type StatusInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          uint32                 `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	Bolusing      bool                   `protobuf:"varint,2,opt,name=bolusing,proto3" json:"bolusing,omitempty"`
+	Suspended     bool                   `protobuf:"varint,3,opt,name=suspended,proto3" json:"suspended,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatusInfo) Reset() {
+	*x = StatusInfo{}
+	mi := &file_medtronic_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusInfo) ProtoMessage() {}
+
+func (x *StatusInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_medtronic_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusInfo.ProtoReflect.Descriptor instead.
+func (*StatusInfo) Descriptor() ([]byte, []int) {
+	return file_medtronic_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *StatusInfo) GetCode() uint32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *StatusInfo) GetBolusing() bool {
+	if x != nil {
+		return x.Bolusing
+	}
+	return false
+}
+
+func (x *StatusInfo) GetSuspended() bool {
+	if x != nil {
+		return x.Suspended
+	}
+	return false
+}
+
+type BatteryInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MilliVolts    int32                  `protobuf:"varint,1,opt,name=milli_volts,json=milliVolts,proto3" json:"milli_volts,omitempty"`
+	LowBattery    bool                   `protobuf:"varint,2,opt,name=low_battery,json=lowBattery,proto3" json:"low_battery,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatteryInfo) Reset() {
+	*x = BatteryInfo{}
+	mi := &file_medtronic_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatteryInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatteryInfo) ProtoMessage() {}
+
+func (x *BatteryInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_medtronic_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatteryInfo.ProtoReflect.Descriptor instead.
+func (*BatteryInfo) Descriptor() ([]byte, []int) {
+	return file_medtronic_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *BatteryInfo) GetMilliVolts() int32 {
+	if x != nil {
+		return x.MilliVolts
+	}
+	return 0
+}
+
+func (x *BatteryInfo) GetLowBattery() bool {
+	if x != nil {
+		return x.LowBattery
+	}
+	return false
+}
+
+type BasalRate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Start         *durationpb.Duration   `protobuf:"bytes,1,opt,name=start,proto3" json:"start,omitempty"`
+	Rate          int32                  `protobuf:"varint,2,opt,name=rate,proto3" json:"rate,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BasalRate) Reset() {
+	*x = BasalRate{}
+	mi := &file_medtronic_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BasalRate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BasalRate) ProtoMessage() {}
+
+func (x *BasalRate) ProtoReflect() protoreflect.Message {
+	mi := &file_medtronic_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BasalRate.ProtoReflect.Descriptor instead.
+func (*BasalRate) Descriptor() ([]byte, []int) {
+	return file_medtronic_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *BasalRate) GetStart() *durationpb.Duration {
+	if x != nil {
+		return x.Start
+	}
+	return nil
+}
+
+func (x *BasalRate) GetRate() int32 {
+	if x != nil {
+		return x.Rate
+	}
+	return 0
+}
+
+type BasalRateSchedule struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rates         []*BasalRate           `protobuf:"bytes,1,rep,name=rates,proto3" json:"rates,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BasalRateSchedule) Reset() {
+	*x = BasalRateSchedule{}
+	mi := &file_medtronic_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BasalRateSchedule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BasalRateSchedule) ProtoMessage() {}
+
+func (x *BasalRateSchedule) ProtoReflect() protoreflect.Message {
+	mi := &file_medtronic_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BasalRateSchedule.ProtoReflect.Descriptor instead.
+func (*BasalRateSchedule) Descriptor() ([]byte, []int) {
+	return file_medtronic_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *BasalRateSchedule) GetRates() []*BasalRate {
+	if x != nil {
+		return x.Rates
+	}
+	return nil
+}
+
+type CarbRatio struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Start *durationpb.Duration   `protobuf:"bytes,1,opt,name=start,proto3" json:"start,omitempty"`
+	// 10x grams/unit or 1000x units/exchange.
+	Ratio         int32     `protobuf:"varint,2,opt,name=ratio,proto3" json:"ratio,omitempty"`
+	Units         CarbUnits `protobuf:"varint,3,opt,name=units,proto3,enum=medtronic.v1.CarbUnits" json:"units,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CarbRatio) Reset() {
+	*x = CarbRatio{}
+	mi := &file_medtronic_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CarbRatio) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CarbRatio) ProtoMessage() {}
+
+func (x *CarbRatio) ProtoReflect() protoreflect.Message {
+	mi := &file_medtronic_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CarbRatio.ProtoReflect.Descriptor instead.
+func (*CarbRatio) Descriptor() ([]byte, []int) {
+	return file_medtronic_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CarbRatio) GetStart() *durationpb.Duration {
+	if x != nil {
+		return x.Start
+	}
+	return nil
+}
+
+func (x *CarbRatio) GetRatio() int32 {
+	if x != nil {
+		return x.Ratio
+	}
+	return 0
+}
+
+func (x *CarbRatio) GetUnits() CarbUnits {
+	if x != nil {
+		return x.Units
+	}
+	return CarbUnits_CARB_UNITS_UNSPECIFIED
+}
+
+type CarbRatioSchedule struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ratios        []*CarbRatio           `protobuf:"bytes,1,rep,name=ratios,proto3" json:"ratios,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CarbRatioSchedule) Reset() {
+	*x = CarbRatioSchedule{}
+	mi := &file_medtronic_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CarbRatioSchedule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CarbRatioSchedule) ProtoMessage() {}
+
+func (x *CarbRatioSchedule) ProtoReflect() protoreflect.Message {
+	mi := &file_medtronic_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CarbRatioSchedule.ProtoReflect.Descriptor instead.
+func (*CarbRatioSchedule) Descriptor() ([]byte, []int) {
+	return file_medtronic_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *CarbRatioSchedule) GetRatios() []*CarbRatio {
+	if x != nil {
+		return x.Ratios
+	}
+	return nil
+}
+
+type GlucoseTarget struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Start         *durationpb.Duration   `protobuf:"bytes,1,opt,name=start,proto3" json:"start,omitempty"`
+	Low           int32                  `protobuf:"varint,2,opt,name=low,proto3" json:"low,omitempty"`
+	High          int32                  `protobuf:"varint,3,opt,name=high,proto3" json:"high,omitempty"`
+	Units         GlucoseUnits           `protobuf:"varint,4,opt,name=units,proto3,enum=medtronic.v1.GlucoseUnits" json:"units,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GlucoseTarget) Reset() {
+	*x = GlucoseTarget{}
+	mi := &file_medtronic_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GlucoseTarget) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GlucoseTarget) ProtoMessage() {}
+
+func (x *GlucoseTarget) ProtoReflect() protoreflect.Message {
+	mi := &file_medtronic_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GlucoseTarget.ProtoReflect.Descriptor instead.
+func (*GlucoseTarget) Descriptor() ([]byte, []int) {
+	return file_medtronic_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GlucoseTarget) GetStart() *durationpb.Duration {
+	if x != nil {
+		return x.Start
+	}
+	return nil
+}
+
+func (x *GlucoseTarget) GetLow() int32 {
+	if x != nil {
+		return x.Low
+	}
+	return 0
+}
+
+func (x *GlucoseTarget) GetHigh() int32 {
+	if x != nil {
+		return x.High
+	}
+	return 0
+}
+
+func (x *GlucoseTarget) GetUnits() GlucoseUnits {
+	if x != nil {
+		return x.Units
+	}
+	return GlucoseUnits_GLUCOSE_UNITS_UNSPECIFIED
+}
+
+type GlucoseTargetSchedule struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Targets       []*GlucoseTarget       `protobuf:"bytes,1,rep,name=targets,proto3" json:"targets,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GlucoseTargetSchedule) Reset() {
+	*x = GlucoseTargetSchedule{}
+	mi := &file_medtronic_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GlucoseTargetSchedule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GlucoseTargetSchedule) ProtoMessage() {}
+
+func (x *GlucoseTargetSchedule) ProtoReflect() protoreflect.Message {
+	mi := &file_medtronic_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GlucoseTargetSchedule.ProtoReflect.Descriptor instead.
+func (*GlucoseTargetSchedule) Descriptor() ([]byte, []int) {
+	return file_medtronic_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GlucoseTargetSchedule) GetTargets() []*GlucoseTarget {
+	if x != nil {
+		return x.Targets
+	}
+	return nil
+}
+
+type InsulinSensitivity struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Start         *durationpb.Duration   `protobuf:"bytes,1,opt,name=start,proto3" json:"start,omitempty"`
+	Sensitivity   int32                  `protobuf:"varint,2,opt,name=sensitivity,proto3" json:"sensitivity,omitempty"`
+	Units         GlucoseUnits           `protobuf:"varint,3,opt,name=units,proto3,enum=medtronic.v1.GlucoseUnits" json:"units,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InsulinSensitivity) Reset() {
+	*x = InsulinSensitivity{}
+	mi := &file_medtronic_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InsulinSensitivity) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InsulinSensitivity) ProtoMessage() {}
+
+func (x *InsulinSensitivity) ProtoReflect() protoreflect.Message {
+	mi := &file_medtronic_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InsulinSensitivity.ProtoReflect.Descriptor instead.
+func (*InsulinSensitivity) Descriptor() ([]byte, []int) {
+	return file_medtronic_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *InsulinSensitivity) GetStart() *durationpb.Duration {
+	if x != nil {
+		return x.Start
+	}
+	return nil
+}
+
+func (x *InsulinSensitivity) GetSensitivity() int32 {
+	if x != nil {
+		return x.Sensitivity
+	}
+	return 0
+}
+
+func (x *InsulinSensitivity) GetUnits() GlucoseUnits {
+	if x != nil {
+		return x.Units
+	}
+	return GlucoseUnits_GLUCOSE_UNITS_UNSPECIFIED
+}
+
+type InsulinSensitivitySchedule struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sensitivities []*InsulinSensitivity  `protobuf:"bytes,1,rep,name=sensitivities,proto3" json:"sensitivities,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InsulinSensitivitySchedule) Reset() {
+	*x = InsulinSensitivitySchedule{}
+	mi := &file_medtronic_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InsulinSensitivitySchedule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InsulinSensitivitySchedule) ProtoMessage() {}
+
+func (x *InsulinSensitivitySchedule) ProtoReflect() protoreflect.Message {
+	mi := &file_medtronic_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InsulinSensitivitySchedule.ProtoReflect.Descriptor instead.
+func (*InsulinSensitivitySchedule) Descriptor() ([]byte, []int) {
+	return file_medtronic_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *InsulinSensitivitySchedule) GetSensitivities() []*InsulinSensitivity {
+	if x != nil {
+		return x.Sensitivities
+	}
+	return nil
+}
+
+type TempBasalInfo struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Duration *durationpb.Duration   `protobuf:"bytes,1,opt,name=duration,proto3" json:"duration,omitempty"`
+	Type     TempBasalType          `protobuf:"varint,2,opt,name=type,proto3,enum=medtronic.v1.TempBasalType" json:"type,omitempty"`
+	// Types that are valid to be assigned to Value:
+	//
+	//	*TempBasalInfo_Rate
+	//	*TempBasalInfo_Percent
+	Value         isTempBasalInfo_Value `protobuf_oneof:"value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TempBasalInfo) Reset() {
+	*x = TempBasalInfo{}
+	mi := &file_medtronic_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TempBasalInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TempBasalInfo) ProtoMessage() {}
+
+func (x *TempBasalInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_medtronic_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TempBasalInfo.ProtoReflect.Descriptor instead.
+func (*TempBasalInfo) Descriptor() ([]byte, []int) {
+	return file_medtronic_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *TempBasalInfo) GetDuration() *durationpb.Duration {
+	if x != nil {
+		return x.Duration
+	}
+	return nil
+}
+
+func (x *TempBasalInfo) GetType() TempBasalType {
+	if x != nil {
+		return x.Type
+	}
+	return TempBasalType_ABSOLUTE
+}
+
+func (x *TempBasalInfo) GetValue() isTempBasalInfo_Value {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *TempBasalInfo) GetRate() int32 {
+	if x != nil {
+		if x, ok := x.Value.(*TempBasalInfo_Rate); ok {
+			return x.Rate
+		}
+	}
+	return 0
+}
+
+func (x *TempBasalInfo) GetPercent() uint32 {
+	if x != nil {
+		if x, ok := x.Value.(*TempBasalInfo_Percent); ok {
+			return x.Percent
+		}
+	}
+	return 0
+}
+
+type isTempBasalInfo_Value interface {
+	isTempBasalInfo_Value()
+}
+
+type TempBasalInfo_Rate struct {
+	Rate int32 `protobuf:"varint,3,opt,name=rate,proto3,oneof"`
+}
+
+type TempBasalInfo_Percent struct {
+	Percent uint32 `protobuf:"varint,4,opt,name=percent,proto3,oneof"`
+}
+
+func (*TempBasalInfo_Rate) isTempBasalInfo_Value() {}
+
+func (*TempBasalInfo_Percent) isTempBasalInfo_Value() {}
+
+type SetAbsoluteTempBasalRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Duration      *durationpb.Duration   `protobuf:"bytes,1,opt,name=duration,proto3" json:"duration,omitempty"`
+	Rate          int32                  `protobuf:"varint,2,opt,name=rate,proto3" json:"rate,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetAbsoluteTempBasalRequest) Reset() {
+	*x = SetAbsoluteTempBasalRequest{}
+	mi := &file_medtronic_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetAbsoluteTempBasalRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetAbsoluteTempBasalRequest) ProtoMessage() {}
+
+func (x *SetAbsoluteTempBasalRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_medtronic_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetAbsoluteTempBasalRequest.ProtoReflect.Descriptor instead.
+func (*SetAbsoluteTempBasalRequest) Descriptor() ([]byte, []int) {
+	return file_medtronic_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *SetAbsoluteTempBasalRequest) GetDuration() *durationpb.Duration {
+	if x != nil {
+		return x.Duration
+	}
+	return nil
+}
+
+func (x *SetAbsoluteTempBasalRequest) GetRate() int32 {
+	if x != nil {
+		return x.Rate
+	}
+	return 0
+}
+
+type HistoryRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only records after this time are returned.
+	Since         *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=since,proto3" json:"since,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistoryRequest) Reset() {
+	*x = HistoryRequest{}
+	mi := &file_medtronic_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryRequest) ProtoMessage() {}
+
+func (x *HistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_medtronic_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryRequest.ProtoReflect.Descriptor instead.
+func (*HistoryRequest) Descriptor() ([]byte, []int) {
+	return file_medtronic_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *HistoryRequest) GetSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Since
+	}
+	return nil
+}
+
+type HistoryRecord struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Record type name, such as "Bolus" or "TempBasalRate".
+	Type     string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	TypeCode uint32                 `protobuf:"varint,2,opt,name=type_code,json=typeCode,proto3" json:"type_code,omitempty"`
+	Time     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=time,proto3" json:"time,omitempty"`
+	Data     []byte                 `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`
+	// JSON encoding of the decoded record information, if any.
+	InfoJson      string `protobuf:"bytes,5,opt,name=info_json,json=infoJson,proto3" json:"info_json,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistoryRecord) Reset() {
+	*x = HistoryRecord{}
+	mi := &file_medtronic_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistoryRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryRecord) ProtoMessage() {}
+
+func (x *HistoryRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_medtronic_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryRecord.ProtoReflect.Descriptor instead.
+func (*HistoryRecord) Descriptor() ([]byte, []int) {
+	return file_medtronic_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *HistoryRecord) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *HistoryRecord) GetTypeCode() uint32 {
+	if x != nil {
+		return x.TypeCode
+	}
+	return 0
+}
+
+func (x *HistoryRecord) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *HistoryRecord) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *HistoryRecord) GetInfoJson() string {
+	if x != nil {
+		return x.InfoJson
+	}
+	return ""
+}
+
+type HistoryPage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Page          int32                  `protobuf:"varint,1,opt,name=page,proto3" json:"page,omitempty"`
+	Records       []*HistoryRecord       `protobuf:"bytes,2,rep,name=records,proto3" json:"records,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistoryPage) Reset() {
+	*x = HistoryPage{}
+	mi := &file_medtronic_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistoryPage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryPage) ProtoMessage() {}
+
+func (x *HistoryPage) ProtoReflect() protoreflect.Message {
+	mi := &file_medtronic_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryPage.ProtoReflect.Descriptor instead.
+func (*HistoryPage) Descriptor() ([]byte, []int) {
+	return file_medtronic_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *HistoryPage) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *HistoryPage) GetRecords() []*HistoryRecord {
+	if x != nil {
+		return x.Records
+	}
+	return nil
+}
+
+type CGMRecord struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Record type name, such as "CGMGlucose" or "CGMSensorTimestamp".
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	TypeCode      uint32                 `protobuf:"varint,2,opt,name=type_code,json=typeCode,proto3" json:"type_code,omitempty"`
+	Time          *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=time,proto3" json:"time,omitempty"`
+	Data          []byte                 `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`
+	Glucose       int32                  `protobuf:"varint,5,opt,name=glucose,proto3" json:"glucose,omitempty"`
+	Value         string                 `protobuf:"bytes,6,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CGMRecord) Reset() {
+	*x = CGMRecord{}
+	mi := &file_medtronic_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CGMRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CGMRecord) ProtoMessage() {}
+
+func (x *CGMRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_medtronic_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CGMRecord.ProtoReflect.Descriptor instead.
+func (*CGMRecord) Descriptor() ([]byte, []int) {
+	return file_medtronic_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *CGMRecord) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *CGMRecord) GetTypeCode() uint32 {
+	if x != nil {
+		return x.TypeCode
+	}
+	return 0
+}
+
+func (x *CGMRecord) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *CGMRecord) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *CGMRecord) GetGlucose() int32 {
+	if x != nil {
+		return x.Glucose
+	}
+	return 0
+}
+
+func (x *CGMRecord) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+type CGMHistoryPage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Page          int32                  `protobuf:"varint,1,opt,name=page,proto3" json:"page,omitempty"`
+	Records       []*CGMRecord           `protobuf:"bytes,2,rep,name=records,proto3" json:"records,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CGMHistoryPage) Reset() {
+	*x = CGMHistoryPage{}
+	mi := &file_medtronic_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CGMHistoryPage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CGMHistoryPage) ProtoMessage() {}
+
+func (x *CGMHistoryPage) ProtoReflect() protoreflect.Message {
+	mi := &file_medtronic_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CGMHistoryPage.ProtoReflect.Descriptor instead.
+func (*CGMHistoryPage) Descriptor() ([]byte, []int) {
+	return file_medtronic_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *CGMHistoryPage) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *CGMHistoryPage) GetRecords() []*CGMRecord {
+	if x != nil {
+		return x.Records
+	}
+	return nil
+}
+
+var File_medtronic_proto protoreflect.FileDescriptor
+
+const file_medtronic_proto_rawDesc = "" +
+	"\n" +
+	"\x0fmedtronic.proto\x12\fmedtronic.v1\x1a\x1egoogle/protobuf/duration.proto\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"*\n" +
+	"\aInsulin\x12\x1f\n" +
+	"\vmilli_units\x18\x01 \x01(\x05R\n" +
+	"milliUnits\"Z\n" +
+	"\n" +
+	"StatusInfo\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\rR\x04code\x12\x1a\n" +
+	"\bbolusing\x18\x02 \x01(\bR\bbolusing\x12\x1c\n" +
+	"\tsuspended\x18\x03 \x01(\bR\tsuspended\"O\n" +
+	"\vBatteryInfo\x12\x1f\n" +
+	"\vmilli_volts\x18\x01 \x01(\x05R\n" +
+	"milliVolts\x12\x1f\n" +
+	"\vlow_battery\x18\x02 \x01(\bR\n" +
+	"lowBattery\"P\n" +
+	"\tBasalRate\x12/\n" +
+	"\x05start\x18\x01 \x01(\v2\x19.google.protobuf.DurationR\x05start\x12\x12\n" +
+	"\x04rate\x18\x02 \x01(\x05R\x04rate\"B\n" +
+	"\x11BasalRateSchedule\x12-\n" +
+	"\x05rates\x18\x01 \x03(\v2\x17.medtronic.v1.BasalRateR\x05rates\"\x81\x01\n" +
+	"\tCarbRatio\x12/\n" +
+	"\x05start\x18\x01 \x01(\v2\x19.google.protobuf.DurationR\x05start\x12\x14\n" +
+	"\x05ratio\x18\x02 \x01(\x05R\x05ratio\x12-\n" +
+	"\x05units\x18\x03 \x01(\x0e2\x17.medtronic.v1.CarbUnitsR\x05units\"D\n" +
+	"\x11CarbRatioSchedule\x12/\n" +
+	"\x06ratios\x18\x01 \x03(\v2\x17.medtronic.v1.CarbRatioR\x06ratios\"\x98\x01\n" +
+	"\rGlucoseTarget\x12/\n" +
+	"\x05start\x18\x01 \x01(\v2\x19.google.protobuf.DurationR\x05start\x12\x10\n" +
+	"\x03low\x18\x02 \x01(\x05R\x03low\x12\x12\n" +
+	"\x04high\x18\x03 \x01(\x05R\x04high\x120\n" +
+	"\x05units\x18\x04 \x01(\x0e2\x1a.medtronic.v1.GlucoseUnitsR\x05units\"N\n" +
+	"\x15GlucoseTargetSchedule\x125\n" +
+	"\atargets\x18\x01 \x03(\v2\x1b.medtronic.v1.GlucoseTargetR\atargets\"\x99\x01\n" +
+	"\x12InsulinSensitivity\x12/\n" +
+	"\x05start\x18\x01 \x01(\v2\x19.google.protobuf.DurationR\x05start\x12 \n" +
+	"\vsensitivity\x18\x02 \x01(\x05R\vsensitivity\x120\n" +
+	"\x05units\x18\x03 \x01(\x0e2\x1a.medtronic.v1.GlucoseUnitsR\x05units\"d\n" +
+	"\x1aInsulinSensitivitySchedule\x12F\n" +
+	"\rsensitivities\x18\x01 \x03(\v2 .medtronic.v1.InsulinSensitivityR\rsensitivities\"\xb2\x01\n" +
+	"\rTempBasalInfo\x125\n" +
+	"\bduration\x18\x01 \x01(\v2\x19.google.protobuf.DurationR\bduration\x12/\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x1b.medtronic.v1.TempBasalTypeR\x04type\x12\x14\n" +
+	"\x04rate\x18\x03 \x01(\x05H\x00R\x04rate\x12\x1a\n" +
+	"\apercent\x18\x04 \x01(\rH\x00R\apercentB\a\n" +
+	"\x05value\"h\n" +
+	"\x1bSetAbsoluteTempBasalRequest\x125\n" +
+	"\bduration\x18\x01 \x01(\v2\x19.google.protobuf.DurationR\bduration\x12\x12\n" +
+	"\x04rate\x18\x02 \x01(\x05R\x04rate\"B\n" +
+	"\x0eHistoryRequest\x120\n" +
+	"\x05since\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x05since\"\xa1\x01\n" +
+	"\rHistoryRecord\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x1b\n" +
+	"\ttype_code\x18\x02 \x01(\rR\btypeCode\x12.\n" +
+	"\x04time\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12\x12\n" +
+	"\x04data\x18\x04 \x01(\fR\x04data\x12\x1b\n" +
+	"\tinfo_json\x18\x05 \x01(\tR\binfoJson\"X\n" +
+	"\vHistoryPage\x12\x12\n" +
+	"\x04page\x18\x01 \x01(\x05R\x04page\x125\n" +
+	"\arecords\x18\x02 \x03(\v2\x1b.medtronic.v1.HistoryRecordR\arecords\"\xb0\x01\n" +
+	"\tCGMRecord\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x1b\n" +
+	"\ttype_code\x18\x02 \x01(\rR\btypeCode\x12.\n" +
+	"\x04time\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12\x12\n" +
+	"\x04data\x18\x04 \x01(\fR\x04data\x12\x18\n" +
+	"\aglucose\x18\x05 \x01(\x05R\aglucose\x12\x14\n" +
+	"\x05value\x18\x06 \x01(\tR\x05value\"W\n" +
+	"\x0eCGMHistoryPage\x12\x12\n" +
+	"\x04page\x18\x01 \x01(\x05R\x04page\x121\n" +
+	"\arecords\x18\x02 \x03(\v2\x17.medtronic.v1.CGMRecordR\arecords*X\n" +
+	"\fGlucoseUnits\x12\x1d\n" +
+	"\x19GLUCOSE_UNITS_UNSPECIFIED\x10\x00\x12\x15\n" +
+	"\x11MG_PER_DECI_LITER\x10\x01\x12\x12\n" +
+	"\x0eMMOL_PER_LITER\x10\x02*A\n" +
+	"\tCarbUnits\x12\x1a\n" +
+	"\x16CARB_UNITS_UNSPECIFIED\x10\x00\x12\t\n" +
+	"\x05GRAMS\x10\x01\x12\r\n" +
+	"\tEXCHANGES\x10\x02**\n" +
+	"\rTempBasalType\x12\f\n" +
+	"\bABSOLUTE\x10\x00\x12\v\n" +
+	"\aPERCENT\x10\x012\xdf\x06\n" +
+	"\x04Pump\x12:\n" +
+	"\x06Status\x12\x16.google.protobuf.Empty\x1a\x18.medtronic.v1.StatusInfo\x12:\n" +
+	"\tReservoir\x12\x16.google.protobuf.Empty\x1a\x15.medtronic.v1.Insulin\x12<\n" +
+	"\aBattery\x12\x16.google.protobuf.Empty\x1a\x19.medtronic.v1.BatteryInfo\x12;\n" +
+	"\x05Clock\x12\x16.google.protobuf.Empty\x1a\x1a.google.protobuf.Timestamp\x12E\n" +
+	"\n" +
+	"BasalRates\x12\x16.google.protobuf.Empty\x1a\x1f.medtronic.v1.BasalRateSchedule\x12E\n" +
+	"\n" +
+	"CarbRatios\x12\x16.google.protobuf.Empty\x1a\x1f.medtronic.v1.CarbRatioSchedule\x12M\n" +
+	"\x0eGlucoseTargets\x12\x16.google.protobuf.Empty\x1a#.medtronic.v1.GlucoseTargetSchedule\x12X\n" +
+	"\x14InsulinSensitivities\x12\x16.google.protobuf.Empty\x1a(.medtronic.v1.InsulinSensitivitySchedule\x12@\n" +
+	"\tTempBasal\x12\x16.google.protobuf.Empty\x1a\x1b.medtronic.v1.TempBasalInfo\x12Y\n" +
+	"\x14SetAbsoluteTempBasal\x12).medtronic.v1.SetAbsoluteTempBasalRequest\x1a\x16.google.protobuf.Empty\x12D\n" +
+	"\aHistory\x12\x1c.medtronic.v1.HistoryRequest\x1a\x19.medtronic.v1.HistoryPage0\x01\x12J\n" +
+	"\n" +
+	"CGMHistory\x12\x1c.medtronic.v1.HistoryRequest\x1a\x1c.medtronic.v1.CGMHistoryPage0\x01B#Z!github.com/thecubic/medtronic/rpcb\x06proto3"
+
+var (
+	file_medtronic_proto_rawDescOnce sync.Once
+	file_medtronic_proto_rawDescData []byte
+)
+
+func file_medtronic_proto_rawDescGZIP() []byte {
+	file_medtronic_proto_rawDescOnce.Do(func() {
+		file_medtronic_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_medtronic_proto_rawDesc), len(file_medtronic_proto_rawDesc)))
+	})
+	return file_medtronic_proto_rawDescData
+}
+
+var file_medtronic_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_medtronic_proto_msgTypes = make([]protoimpl.MessageInfo, 18)
+var file_medtronic_proto_goTypes = []any{
+	(GlucoseUnits)(0),                   // 0: medtronic.v1.GlucoseUnits
+	(CarbUnits)(0),                      // 1: medtronic.v1.CarbUnits
+	(TempBasalType)(0),                  // 2: medtronic.v1.TempBasalType
+	(*Insulin)(nil),                     // 3: medtronic.v1.Insulin
+	(*StatusInfo)(nil),                  // 4: medtronic.v1.StatusInfo
+	(*BatteryInfo)(nil),                 // 5: medtronic.v1.BatteryInfo
+	(*BasalRate)(nil),                   // 6: medtronic.v1.BasalRate
+	(*BasalRateSchedule)(nil),           // 7: medtronic.v1.BasalRateSchedule
+	(*CarbRatio)(nil),                   // 8: medtronic.v1.CarbRatio
+	(*CarbRatioSchedule)(nil),           // 9: medtronic.v1.CarbRatioSchedule
+	(*GlucoseTarget)(nil),               // 10: medtronic.v1.GlucoseTarget
+	(*GlucoseTargetSchedule)(nil),       // 11: medtronic.v1.GlucoseTargetSchedule
+	(*InsulinSensitivity)(nil),          // 12: medtronic.v1.InsulinSensitivity
+	(*InsulinSensitivitySchedule)(nil),  // 13: medtronic.v1.InsulinSensitivitySchedule
+	(*TempBasalInfo)(nil),               // 14: medtronic.v1.TempBasalInfo
+	(*SetAbsoluteTempBasalRequest)(nil), // 15: medtronic.v1.SetAbsoluteTempBasalRequest
+	(*HistoryRequest)(nil),              // 16: medtronic.v1.HistoryRequest
+	(*HistoryRecord)(nil),               // 17: medtronic.v1.HistoryRecord
+	(*HistoryPage)(nil),                 // 18: medtronic.v1.HistoryPage
+	(*CGMRecord)(nil),                   // 19: medtronic.v1.CGMRecord
+	(*CGMHistoryPage)(nil),              // 20: medtronic.v1.CGMHistoryPage
+	(*durationpb.Duration)(nil),         // 21: google.protobuf.Duration
+	(*timestamppb.Timestamp)(nil),       // 22: google.protobuf.Timestamp
+	(*emptypb.Empty)(nil),               // 23: google.protobuf.Empty
+}
+var file_medtronic_proto_depIdxs = []int32{
+	21, // 0: medtronic.v1.BasalRate.start:type_name -> google.protobuf.Duration
+	6,  // 1: medtronic.v1.BasalRateSchedule.rates:type_name -> medtronic.v1.BasalRate
+	21, // 2: medtronic.v1.CarbRatio.start:type_name -> google.protobuf.Duration
+	1,  // 3: medtronic.v1.CarbRatio.units:type_name -> medtronic.v1.CarbUnits
+	8,  // 4: medtronic.v1.CarbRatioSchedule.ratios:type_name -> medtronic.v1.CarbRatio
+	21, // 5: medtronic.v1.GlucoseTarget.start:type_name -> google.protobuf.Duration
+	0,  // 6: medtronic.v1.GlucoseTarget.units:type_name -> medtronic.v1.GlucoseUnits
+	10, // 7: medtronic.v1.GlucoseTargetSchedule.targets:type_name -> medtronic.v1.GlucoseTarget
+	21, // 8: medtronic.v1.InsulinSensitivity.start:type_name -> google.protobuf.Duration
+	0,  // 9: medtronic.v1.InsulinSensitivity.units:type_name -> medtronic.v1.GlucoseUnits
+	12, // 10: medtronic.v1.InsulinSensitivitySchedule.sensitivities:type_name -> medtronic.v1.InsulinSensitivity
+	21, // 11: medtronic.v1.TempBasalInfo.duration:type_name -> google.protobuf.Duration
+	2,  // 12: medtronic.v1.TempBasalInfo.type:type_name -> medtronic.v1.TempBasalType
+	21, // 13: medtronic.v1.SetAbsoluteTempBasalRequest.duration:type_name -> google.protobuf.Duration
+	22, // 14: medtronic.v1.HistoryRequest.since:type_name -> google.protobuf.Timestamp
+	22, // 15: medtronic.v1.HistoryRecord.time:type_name -> google.protobuf.Timestamp
+	17, // 16: medtronic.v1.HistoryPage.records:type_name -> medtronic.v1.HistoryRecord
+	22, // 17: medtronic.v1.CGMRecord.time:type_name -> google.protobuf.Timestamp
+	19, // 18: medtronic.v1.CGMHistoryPage.records:type_name -> medtronic.v1.CGMRecord
+	23, // 19: medtronic.v1.Pump.Status:input_type -> google.protobuf.Empty
+	23, // 20: medtronic.v1.Pump.Reservoir:input_type -> google.protobuf.Empty
+	23, // 21: medtronic.v1.Pump.Battery:input_type -> google.protobuf.Empty
+	23, // 22: medtronic.v1.Pump.Clock:input_type -> google.protobuf.Empty
+	23, // 23: medtronic.v1.Pump.BasalRates:input_type -> google.protobuf.Empty
+	23, // 24: medtronic.v1.Pump.CarbRatios:input_type -> google.protobuf.Empty
+	23, // 25: medtronic.v1.Pump.GlucoseTargets:input_type -> google.protobuf.Empty
+	23, // 26: medtronic.v1.Pump.InsulinSensitivities:input_type -> google.protobuf.Empty
+	23, // 27: medtronic.v1.Pump.TempBasal:input_type -> google.protobuf.Empty
+	15, // 28: medtronic.v1.Pump.SetAbsoluteTempBasal:input_type -> medtronic.v1.SetAbsoluteTempBasalRequest
+	16, // 29: medtronic.v1.Pump.History:input_type -> medtronic.v1.HistoryRequest
+	16, // 30: medtronic.v1.Pump.CGMHistory:input_type -> medtronic.v1.HistoryRequest
+	4,  // 31: medtronic.v1.Pump.Status:output_type -> medtronic.v1.StatusInfo
+	3,  // 32: medtronic.v1.Pump.Reservoir:output_type -> medtronic.v1.Insulin
+	5,  // 33: medtronic.v1.Pump.Battery:output_type -> medtronic.v1.BatteryInfo
+	22, // 34: medtronic.v1.Pump.Clock:output_type -> google.protobuf.Timestamp
+	7,  // 35: medtronic.v1.Pump.BasalRates:output_type -> medtronic.v1.BasalRateSchedule
+	9,  // 36: medtronic.v1.Pump.CarbRatios:output_type -> medtronic.v1.CarbRatioSchedule
+	11, // 37: medtronic.v1.Pump.GlucoseTargets:output_type -> medtronic.v1.GlucoseTargetSchedule
+	13, // 38: medtronic.v1.Pump.InsulinSensitivities:output_type -> medtronic.v1.InsulinSensitivitySchedule
+	14, // 39: medtronic.v1.Pump.TempBasal:output_type -> medtronic.v1.TempBasalInfo
+	23, // 40: medtronic.v1.Pump.SetAbsoluteTempBasal:output_type -> google.protobuf.Empty
+	18, // 41: medtronic.v1.Pump.History:output_type -> medtronic.v1.HistoryPage
+	20, // 42: medtronic.v1.Pump.CGMHistory:output_type -> medtronic.v1.CGMHistoryPage
+	31, // [31:43] is the sub-list for method output_type
+	19, // [19:31] is the sub-list for method input_type
+	19, // [19:19] is the sub-list for extension type_name
+	19, // [19:19] is the sub-list for extension extendee
+	0,  // [0:19] is the sub-list for field type_name
+}
+
+func init() { file_medtronic_proto_init() }
+func file_medtronic_proto_init() {
+	if File_medtronic_proto != nil {
+		return
+	}
+	file_medtronic_proto_msgTypes[11].OneofWrappers = []any{
+		(*TempBasalInfo_Rate)(nil),
+		(*TempBasalInfo_Percent)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_medtronic_proto_rawDesc), len(file_medtronic_proto_rawDesc)),
+			NumEnums:      3,
+			NumMessages:   18,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_medtronic_proto_goTypes,
+		DependencyIndexes: file_medtronic_proto_depIdxs,
+		EnumInfos:         file_medtronic_proto_enumTypes,
+		MessageInfos:      file_medtronic_proto_msgTypes,
+	}.Build()
+	File_medtronic_proto = out.File
+	file_medtronic_proto_goTypes = nil
+	file_medtronic_proto_depIdxs = nil
+}
@@ -0,0 +1,563 @@
+// Protocol buffer definitions for controlling a Medtronic pump
+// through a pump session (see medtronic.Session).
+//
+// Insulin amounts and rates are in milliUnits (per hour, for rates),
+// glucose values are in mg/dL or μmol/L according to the units field,
+// and times of day are offsets from midnight.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: medtronic.proto
+
+package rpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Pump_Status_FullMethodName               = "/medtronic.v1.Pump/Status"
+	Pump_Reservoir_FullMethodName            = "/medtronic.v1.Pump/Reservoir"
+	Pump_Battery_FullMethodName              = "/medtronic.v1.Pump/Battery"
+	Pump_Clock_FullMethodName                = "/medtronic.v1.Pump/Clock"
+	Pump_BasalRates_FullMethodName           = "/medtronic.v1.Pump/BasalRates"
+	Pump_CarbRatios_FullMethodName           = "/medtronic.v1.Pump/CarbRatios"
+	Pump_GlucoseTargets_FullMethodName       = "/medtronic.v1.Pump/GlucoseTargets"
+	Pump_InsulinSensitivities_FullMethodName = "/medtronic.v1.Pump/InsulinSensitivities"
+	Pump_TempBasal_FullMethodName            = "/medtronic.v1.Pump/TempBasal"
+	Pump_SetAbsoluteTempBasal_FullMethodName = "/medtronic.v1.Pump/SetAbsoluteTempBasal"
+	Pump_History_FullMethodName              = "/medtronic.v1.Pump/History"
+	Pump_CGMHistory_FullMethodName           = "/medtronic.v1.Pump/CGMHistory"
+)
+
+// PumpClient is the client API for Pump service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type PumpClient interface {
+	Status(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*StatusInfo, error)
+	Reservoir(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Insulin, error)
+	Battery(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*BatteryInfo, error)
+	Clock(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*timestamppb.Timestamp, error)
+	BasalRates(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*BasalRateSchedule, error)
+	CarbRatios(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*CarbRatioSchedule, error)
+	GlucoseTargets(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*GlucoseTargetSchedule, error)
+	InsulinSensitivities(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*InsulinSensitivitySchedule, error)
+	TempBasal(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*TempBasalInfo, error)
+	SetAbsoluteTempBasal(ctx context.Context, in *SetAbsoluteTempBasalRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// History streams pump history records one page at a time,
+	// most recent first, as each page is downloaded.
+	History(ctx context.Context, in *HistoryRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[HistoryPage], error)
+	// CGMHistory streams CGM records one page at a time,
+	// most recent first, as each page is downloaded.
+	CGMHistory(ctx context.Context, in *HistoryRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CGMHistoryPage], error)
+}
+
+type pumpClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPumpClient(cc grpc.ClientConnInterface) PumpClient {
+	return &pumpClient{cc}
+}
+
+func (c *pumpClient) Status(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*StatusInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StatusInfo)
+	err := c.cc.Invoke(ctx, Pump_Status_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pumpClient) Reservoir(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Insulin, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Insulin)
+	err := c.cc.Invoke(ctx, Pump_Reservoir_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pumpClient) Battery(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*BatteryInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatteryInfo)
+	err := c.cc.Invoke(ctx, Pump_Battery_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pumpClient) Clock(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*timestamppb.Timestamp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(timestamppb.Timestamp)
+	err := c.cc.Invoke(ctx, Pump_Clock_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pumpClient) BasalRates(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*BasalRateSchedule, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BasalRateSchedule)
+	err := c.cc.Invoke(ctx, Pump_BasalRates_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pumpClient) CarbRatios(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*CarbRatioSchedule, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CarbRatioSchedule)
+	err := c.cc.Invoke(ctx, Pump_CarbRatios_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pumpClient) GlucoseTargets(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*GlucoseTargetSchedule, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GlucoseTargetSchedule)
+	err := c.cc.Invoke(ctx, Pump_GlucoseTargets_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pumpClient) InsulinSensitivities(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*InsulinSensitivitySchedule, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InsulinSensitivitySchedule)
+	err := c.cc.Invoke(ctx, Pump_InsulinSensitivities_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pumpClient) TempBasal(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*TempBasalInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TempBasalInfo)
+	err := c.cc.Invoke(ctx, Pump_TempBasal_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pumpClient) SetAbsoluteTempBasal(ctx context.Context, in *SetAbsoluteTempBasalRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Pump_SetAbsoluteTempBasal_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pumpClient) History(ctx context.Context, in *HistoryRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[HistoryPage], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Pump_ServiceDesc.Streams[0], Pump_History_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[HistoryRequest, HistoryPage]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Pump_HistoryClient = grpc.ServerStreamingClient[HistoryPage]
+
+func (c *pumpClient) CGMHistory(ctx context.Context, in *HistoryRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CGMHistoryPage], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Pump_ServiceDesc.Streams[1], Pump_CGMHistory_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[HistoryRequest, CGMHistoryPage]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Pump_CGMHistoryClient = grpc.ServerStreamingClient[CGMHistoryPage]
+
+// PumpServer is the server API for Pump service.
+// All implementations must embed UnimplementedPumpServer
+// for forward compatibility.
+type PumpServer interface {
+	Status(context.Context, *emptypb.Empty) (*StatusInfo, error)
+	Reservoir(context.Context, *emptypb.Empty) (*Insulin, error)
+	Battery(context.Context, *emptypb.Empty) (*BatteryInfo, error)
+	Clock(context.Context, *emptypb.Empty) (*timestamppb.Timestamp, error)
+	BasalRates(context.Context, *emptypb.Empty) (*BasalRateSchedule, error)
+	CarbRatios(context.Context, *emptypb.Empty) (*CarbRatioSchedule, error)
+	GlucoseTargets(context.Context, *emptypb.Empty) (*GlucoseTargetSchedule, error)
+	InsulinSensitivities(context.Context, *emptypb.Empty) (*InsulinSensitivitySchedule, error)
+	TempBasal(context.Context, *emptypb.Empty) (*TempBasalInfo, error)
+	SetAbsoluteTempBasal(context.Context, *SetAbsoluteTempBasalRequest) (*emptypb.Empty, error)
+	// History streams pump history records one page at a time,
+	// most recent first, as each page is downloaded.
+	History(*HistoryRequest, grpc.ServerStreamingServer[HistoryPage]) error
+	// CGMHistory streams CGM records one page at a time,
+	// most recent first, as each page is downloaded.
+	CGMHistory(*HistoryRequest, grpc.ServerStreamingServer[CGMHistoryPage]) error
+	mustEmbedUnimplementedPumpServer()
+}
+
+// UnimplementedPumpServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedPumpServer struct{}
+
+func (UnimplementedPumpServer) Status(context.Context, *emptypb.Empty) (*StatusInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Status not implemented")
+}
+func (UnimplementedPumpServer) Reservoir(context.Context, *emptypb.Empty) (*Insulin, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Reservoir not implemented")
+}
+func (UnimplementedPumpServer) Battery(context.Context, *emptypb.Empty) (*BatteryInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Battery not implemented")
+}
+func (UnimplementedPumpServer) Clock(context.Context, *emptypb.Empty) (*timestamppb.Timestamp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Clock not implemented")
+}
+func (UnimplementedPumpServer) BasalRates(context.Context, *emptypb.Empty) (*BasalRateSchedule, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BasalRates not implemented")
+}
+func (UnimplementedPumpServer) CarbRatios(context.Context, *emptypb.Empty) (*CarbRatioSchedule, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CarbRatios not implemented")
+}
+func (UnimplementedPumpServer) GlucoseTargets(context.Context, *emptypb.Empty) (*GlucoseTargetSchedule, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GlucoseTargets not implemented")
+}
+func (UnimplementedPumpServer) InsulinSensitivities(context.Context, *emptypb.Empty) (*InsulinSensitivitySchedule, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method InsulinSensitivities not implemented")
+}
+func (UnimplementedPumpServer) TempBasal(context.Context, *emptypb.Empty) (*TempBasalInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TempBasal not implemented")
+}
+func (UnimplementedPumpServer) SetAbsoluteTempBasal(context.Context, *SetAbsoluteTempBasalRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetAbsoluteTempBasal not implemented")
+}
+func (UnimplementedPumpServer) History(*HistoryRequest, grpc.ServerStreamingServer[HistoryPage]) error {
+	return status.Errorf(codes.Unimplemented, "method History not implemented")
+}
+func (UnimplementedPumpServer) CGMHistory(*HistoryRequest, grpc.ServerStreamingServer[CGMHistoryPage]) error {
+	return status.Errorf(codes.Unimplemented, "method CGMHistory not implemented")
+}
+func (UnimplementedPumpServer) mustEmbedUnimplementedPumpServer() {}
+func (UnimplementedPumpServer) testEmbeddedByValue()              {}
+
+// UnsafePumpServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PumpServer will
+// result in compilation errors.
+type UnsafePumpServer interface {
+	mustEmbedUnimplementedPumpServer()
+}
+
+func RegisterPumpServer(s grpc.ServiceRegistrar, srv PumpServer) {
+	// If the following call pancis, it indicates UnimplementedPumpServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Pump_ServiceDesc, srv)
+}
+
+func _Pump_Status_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PumpServer).Status(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Pump_Status_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PumpServer).Status(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Pump_Reservoir_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PumpServer).Reservoir(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Pump_Reservoir_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PumpServer).Reservoir(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Pump_Battery_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PumpServer).Battery(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Pump_Battery_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PumpServer).Battery(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Pump_Clock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PumpServer).Clock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Pump_Clock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PumpServer).Clock(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Pump_BasalRates_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PumpServer).BasalRates(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Pump_BasalRates_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PumpServer).BasalRates(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Pump_CarbRatios_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PumpServer).CarbRatios(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Pump_CarbRatios_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PumpServer).CarbRatios(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Pump_GlucoseTargets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PumpServer).GlucoseTargets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Pump_GlucoseTargets_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PumpServer).GlucoseTargets(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Pump_InsulinSensitivities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PumpServer).InsulinSensitivities(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Pump_InsulinSensitivities_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PumpServer).InsulinSensitivities(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Pump_TempBasal_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PumpServer).TempBasal(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Pump_TempBasal_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PumpServer).TempBasal(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Pump_SetAbsoluteTempBasal_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetAbsoluteTempBasalRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PumpServer).SetAbsoluteTempBasal(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Pump_SetAbsoluteTempBasal_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PumpServer).SetAbsoluteTempBasal(ctx, req.(*SetAbsoluteTempBasalRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Pump_History_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(HistoryRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PumpServer).History(m, &grpc.GenericServerStream[HistoryRequest, HistoryPage]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Pump_HistoryServer = grpc.ServerStreamingServer[HistoryPage]
+
+func _Pump_CGMHistory_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(HistoryRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PumpServer).CGMHistory(m, &grpc.GenericServerStream[HistoryRequest, CGMHistoryPage]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Pump_CGMHistoryServer = grpc.ServerStreamingServer[CGMHistoryPage]
+
+// Pump_ServiceDesc is the grpc.ServiceDesc for Pump service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Pump_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "medtronic.v1.Pump",
+	HandlerType: (*PumpServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Status",
+			Handler:    _Pump_Status_Handler,
+		},
+		{
+			MethodName: "Reservoir",
+			Handler:    _Pump_Reservoir_Handler,
+		},
+		{
+			MethodName: "Battery",
+			Handler:    _Pump_Battery_Handler,
+		},
+		{
+			MethodName: "Clock",
+			Handler:    _Pump_Clock_Handler,
+		},
+		{
+			MethodName: "BasalRates",
+			Handler:    _Pump_BasalRates_Handler,
+		},
+		{
+			MethodName: "CarbRatios",
+			Handler:    _Pump_CarbRatios_Handler,
+		},
+		{
+			MethodName: "GlucoseTargets",
+			Handler:    _Pump_GlucoseTargets_Handler,
+		},
+		{
+			MethodName: "InsulinSensitivities",
+			Handler:    _Pump_InsulinSensitivities_Handler,
+		},
+		{
+			MethodName: "TempBasal",
+			Handler:    _Pump_TempBasal_Handler,
+		},
+		{
+			MethodName: "SetAbsoluteTempBasal",
+			Handler:    _Pump_SetAbsoluteTempBasal_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "History",
+			Handler:       _Pump_History_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "CGMHistory",
+			Handler:       _Pump_CGMHistory_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "medtronic.proto",
+}
@@ -0,0 +1,259 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/thecubic/medtronic"
+)
+
+// Server implements PumpServer using a pump session.
+type Server struct {
+	UnimplementedPumpServer
+	session *medtronic.Session
+}
+
+// NewServer returns a Server that performs requests using the given session.
+func NewServer(s *medtronic.Session) *Server {
+	return &Server{session: s}
+}
+
+// do performs op in the session with the given priority,
+// governed by the RPC's context.
+func (srv *Server) do(ctx context.Context, pri medtronic.Priority, op func(*medtronic.Pump)) error {
+	return statusError(srv.session.DoContext(ctx, pri, op))
+}
+
+// statusError converts a pump error to a gRPC status error.
+func statusError(err error) error {
+	if err == nil {
+		return nil
+	}
+	code := codes.Unknown
+	var noResponse medtronic.NoResponseError
+	var invalid medtronic.InvalidCommandError
+	switch {
+	case err == medtronic.ErrSessionClosed:
+		code = codes.Unavailable
+	case errors.Is(err, context.Canceled):
+		code = codes.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		code = codes.DeadlineExceeded
+	case errors.As(err, &noResponse):
+		code = codes.Unavailable
+	case errors.As(err, &invalid):
+		code = codes.FailedPrecondition
+	}
+	return status.Error(code, err.Error())
+}
+
+func timeOfDay(t medtronic.TimeOfDay) *durationpb.Duration {
+	return durationpb.New(time.Duration(t))
+}
+
+// Status returns the pump's status.
+func (srv *Server) Status(ctx context.Context, _ *emptypb.Empty) (*StatusInfo, error) {
+	var v medtronic.StatusInfo
+	err := srv.do(ctx, medtronic.NormalPriority, func(pump *medtronic.Pump) { v = pump.Status() })
+	if err != nil {
+		return nil, err
+	}
+	return &StatusInfo{Code: uint32(v.Code), Bolusing: v.Bolusing, Suspended: v.Suspended}, nil
+}
+
+// Reservoir returns the amount of insulin remaining.
+func (srv *Server) Reservoir(ctx context.Context, _ *emptypb.Empty) (*Insulin, error) {
+	var v medtronic.Insulin
+	err := srv.do(ctx, medtronic.NormalPriority, func(pump *medtronic.Pump) { v = pump.Reservoir() })
+	if err != nil {
+		return nil, err
+	}
+	return &Insulin{MilliUnits: int32(v)}, nil
+}
+
+// Battery returns the pump's battery information.
+func (srv *Server) Battery(ctx context.Context, _ *emptypb.Empty) (*BatteryInfo, error) {
+	var v medtronic.BatteryInfo
+	err := srv.do(ctx, medtronic.NormalPriority, func(pump *medtronic.Pump) { v = pump.Battery() })
+	if err != nil {
+		return nil, err
+	}
+	return &BatteryInfo{MilliVolts: int32(v.Voltage), LowBattery: v.LowBattery}, nil
+}
+
+// Clock returns the time according to the pump's clock.
+func (srv *Server) Clock(ctx context.Context, _ *emptypb.Empty) (*timestamppb.Timestamp, error) {
+	var v time.Time
+	err := srv.do(ctx, medtronic.NormalPriority, func(pump *medtronic.Pump) { v = pump.Clock() })
+	if err != nil {
+		return nil, err
+	}
+	return timestamppb.New(v), nil
+}
+
+// BasalRates returns the pump's basal rate schedule.
+func (srv *Server) BasalRates(ctx context.Context, _ *emptypb.Empty) (*BasalRateSchedule, error) {
+	var v medtronic.BasalRateSchedule
+	err := srv.do(ctx, medtronic.NormalPriority, func(pump *medtronic.Pump) { v = pump.BasalRates() })
+	if err != nil {
+		return nil, err
+	}
+	sched := &BasalRateSchedule{}
+	for _, r := range v {
+		sched.Rates = append(sched.Rates, &BasalRate{Start: timeOfDay(r.Start), Rate: int32(r.Rate)})
+	}
+	return sched, nil
+}
+
+// CarbRatios returns the pump's carb ratio schedule.
+func (srv *Server) CarbRatios(ctx context.Context, _ *emptypb.Empty) (*CarbRatioSchedule, error) {
+	var v medtronic.CarbRatioSchedule
+	err := srv.do(ctx, medtronic.NormalPriority, func(pump *medtronic.Pump) { v = pump.CarbRatios() })
+	if err != nil {
+		return nil, err
+	}
+	sched := &CarbRatioSchedule{}
+	for _, r := range v {
+		sched.Ratios = append(sched.Ratios, &CarbRatio{
+			Start: timeOfDay(r.Start),
+			Ratio: int32(r.Ratio),
+			Units: CarbUnits(r.Units),
+		})
+	}
+	return sched, nil
+}
+
+// GlucoseTargets returns the pump's glucose target schedule.
+func (srv *Server) GlucoseTargets(ctx context.Context, _ *emptypb.Empty) (*GlucoseTargetSchedule, error) {
+	var v medtronic.GlucoseTargetSchedule
+	err := srv.do(ctx, medtronic.NormalPriority, func(pump *medtronic.Pump) { v = pump.GlucoseTargets() })
+	if err != nil {
+		return nil, err
+	}
+	sched := &GlucoseTargetSchedule{}
+	for _, t := range v {
+		sched.Targets = append(sched.Targets, &GlucoseTarget{
+			Start: timeOfDay(t.Start),
+			Low:   int32(t.Low),
+			High:  int32(t.High),
+			Units: GlucoseUnits(t.Units),
+		})
+	}
+	return sched, nil
+}
+
+// InsulinSensitivities returns the pump's insulin sensitivity schedule.
+func (srv *Server) InsulinSensitivities(ctx context.Context, _ *emptypb.Empty) (*InsulinSensitivitySchedule, error) {
+	var v medtronic.InsulinSensitivitySchedule
+	err := srv.do(ctx, medtronic.NormalPriority, func(pump *medtronic.Pump) { v = pump.InsulinSensitivities() })
+	if err != nil {
+		return nil, err
+	}
+	sched := &InsulinSensitivitySchedule{}
+	for _, s := range v {
+		sched.Sensitivities = append(sched.Sensitivities, &InsulinSensitivity{
+			Start:       timeOfDay(s.Start),
+			Sensitivity: int32(s.Sensitivity),
+			Units:       GlucoseUnits(s.Units),
+		})
+	}
+	return sched, nil
+}
+
+// TempBasal returns the pump's current temporary basal setting.
+func (srv *Server) TempBasal(ctx context.Context, _ *emptypb.Empty) (*TempBasalInfo, error) {
+	var v medtronic.TempBasalInfo
+	err := srv.do(ctx, medtronic.NormalPriority, func(pump *medtronic.Pump) { v = pump.TempBasal() })
+	if err != nil {
+		return nil, err
+	}
+	info := &TempBasalInfo{
+		Duration: durationpb.New(v.Duration),
+		Type:     TempBasalType(v.Type),
+	}
+	if v.Rate != nil {
+		info.Value = &TempBasalInfo_Rate{Rate: int32(*v.Rate)}
+	} else if v.Percent != nil {
+		info.Value = &TempBasalInfo_Percent{Percent: uint32(*v.Percent)}
+	}
+	return info, nil
+}
+
+// SetAbsoluteTempBasal sets a temporary basal with the given absolute rate and duration.
+func (srv *Server) SetAbsoluteTempBasal(ctx context.Context, req *SetAbsoluteTempBasalRequest) (*emptypb.Empty, error) {
+	d := req.GetDuration().AsDuration()
+	rate := medtronic.Insulin(req.GetRate())
+	err := srv.do(ctx, medtronic.HighPriority, func(pump *medtronic.Pump) { pump.SetAbsoluteTempBasal(d, rate) })
+	if err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// History streams the pump history records since the requested time, one page at a time.
+func (srv *Server) History(req *HistoryRequest, stream Pump_HistoryServer) error {
+	ctx := stream.Context()
+	since := req.GetSince().AsTime()
+	err := srv.session.HistoryPagesContext(ctx, since, func(page int, records medtronic.History) error {
+		p := &HistoryPage{Page: int32(page)}
+		for _, r := range records {
+			p.Records = append(p.Records, historyRecord(r))
+		}
+		return stream.Send(p)
+	})
+	return statusError(err)
+}
+
+func historyRecord(r medtronic.HistoryRecord) *HistoryRecord {
+	rec := &HistoryRecord{
+		Type:     r.Type().String(),
+		TypeCode: uint32(r.Type()),
+		Data:     r.Data,
+	}
+	if !r.Time.IsZero() {
+		rec.Time = timestamppb.New(r.Time)
+	}
+	if r.Info != nil {
+		b, err := json.Marshal(r.Info)
+		if err == nil {
+			rec.InfoJson = string(b)
+		}
+	}
+	return rec
+}
+
+// CGMHistory streams the CGM records since the requested time, one page at a time.
+func (srv *Server) CGMHistory(req *HistoryRequest, stream Pump_CGMHistoryServer) error {
+	ctx := stream.Context()
+	since := req.GetSince().AsTime()
+	err := srv.session.CGMHistoryPagesContext(ctx, since, func(page int, records medtronic.CGMHistory) error {
+		p := &CGMHistoryPage{Page: int32(page)}
+		for _, r := range records {
+			p.Records = append(p.Records, cgmRecord(r))
+		}
+		return stream.Send(p)
+	})
+	return statusError(err)
+}
+
+func cgmRecord(r medtronic.CGMRecord) *CGMRecord {
+	rec := &CGMRecord{
+		Type:     r.Type.String(),
+		TypeCode: uint32(r.Type),
+		Data:     r.Data,
+		Glucose:  int32(r.Glucose),
+		Value:    r.Value,
+	}
+	if !r.Time.IsZero() {
+		rec.Time = timestamppb.New(r.Time)
+	}
+	return rec
+}
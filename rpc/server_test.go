@@ -0,0 +1,85 @@
+package rpc
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/ecc1/radio"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/thecubic/medtronic"
+	"github.com/thecubic/medtronic/simulator"
+)
+
+func testServer(t *testing.T) *Server {
+	log.SetOutput(ioutil.Discard)
+	config := simulator.DefaultConfig("123456", "523")
+	pump := medtronic.OpenWith(medtronic.Options{
+		PumpID: config.ID,
+		Radio:  func() radio.Interface { return simulator.New(config) },
+	})
+	if pump.Error() != nil {
+		t.Fatal(pump.Error())
+	}
+	s := medtronic.NewSession(pump)
+	t.Cleanup(s.Close)
+	return NewServer(s)
+}
+
+// historyStream implements Pump_HistoryServer for testing.
+type historyStream struct {
+	grpc.ServerStream
+	ctx   context.Context
+	pages []*HistoryPage
+}
+
+func (s *historyStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *historyStream) Send(p *HistoryPage) error {
+	s.pages = append(s.pages, p)
+	return nil
+}
+
+func TestServer(t *testing.T) {
+	srv := testServer(t)
+	r, err := srv.Reservoir(context.Background(), &emptypb.Empty{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.GetMilliUnits() == 0 {
+		t.Errorf("Reservoir() == %v", r)
+	}
+	req := &HistoryRequest{Since: timestamppb.New(time.Now().Add(-time.Hour))}
+	stream := &historyStream{ctx: context.Background()}
+	err = srv.History(req, stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stream.pages) == 0 {
+		t.Errorf("History sent no pages")
+	}
+}
+
+func TestServerCanceled(t *testing.T) {
+	srv := testServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := &HistoryRequest{Since: timestamppb.New(time.Time{})}
+	stream := &historyStream{ctx: ctx}
+	err := srv.History(req, stream)
+	if status.Code(err) != codes.Canceled {
+		t.Errorf("History returned %v, want code %v", err, codes.Canceled)
+	}
+	if len(stream.pages) != 0 {
+		t.Errorf("History sent %d pages after context was canceled", len(stream.pages))
+	}
+}
@@ -0,0 +1,318 @@
+package medtronic
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Priority determines the order in which queued session requests are performed.
+type Priority int
+
+const (
+	// LowPriority is used for bulk transfers such as history pages.
+	LowPriority Priority = iota
+	// NormalPriority is used for ordinary queries and settings.
+	NormalPriority
+	// HighPriority is used for commands that affect insulin delivery.
+	HighPriority
+
+	numPriorities = int(HighPriority) + 1
+)
+
+// ErrSessionClosed is returned for requests made after a session is closed.
+var ErrSessionClosed = fmt.Errorf("pump session is closed")
+
+// Session owns a pump and serializes requests from multiple goroutines.
+// Requests are performed one at a time, highest priority first,
+// and in the order they were made within the same priority.
+// Long transfers are split into one request per page,
+// so a high-priority command can overtake them between pages.
+type Session struct {
+	pump   *Pump
+	mu     sync.Mutex
+	cond   *sync.Cond
+	queues [numPriorities][]*request
+	closed bool
+	done   chan struct{}
+}
+
+type request struct {
+	op   func(*Pump)
+	err  error
+	done chan struct{}
+}
+
+// NewSession starts a session that performs requests using the given pump.
+// The pump must not be used directly while the session is open.
+func NewSession(pump *Pump) *Session {
+	s := &Session{pump: pump, done: make(chan struct{})}
+	s.cond = sync.NewCond(&s.mu)
+	go s.run()
+	return s
+}
+
+// Do queues op with the given priority, waits for it to be performed,
+// and returns the pump's resulting error state.
+// The error state is cleared before and after op is called.
+func (s *Session) Do(pri Priority, op func(pump *Pump)) error {
+	if pri < LowPriority || pri > HighPriority {
+		return fmt.Errorf("invalid session priority %d", pri)
+	}
+	req := &request{op: op, done: make(chan struct{})}
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return ErrSessionClosed
+	}
+	s.queues[pri] = append(s.queues[pri], req)
+	s.cond.Signal()
+	s.mu.Unlock()
+	<-req.done
+	return req.err
+}
+
+// DoContext is like Do, but the pump's communications in op
+// are governed by ctx, as with SetContext.
+func (s *Session) DoContext(ctx context.Context, pri Priority, op func(pump *Pump)) error {
+	return s.Do(pri, func(pump *Pump) {
+		defer pump.withContext(ctx)()
+		op(pump)
+	})
+}
+
+// Pending returns the number of requests waiting to be performed.
+func (s *Session) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pending()
+}
+
+func (s *Session) pending() int {
+	n := 0
+	for _, q := range s.queues {
+		n += len(q)
+	}
+	return n
+}
+
+// next removes and returns the oldest request with the highest priority.
+func (s *Session) next() *request {
+	for pri := numPriorities - 1; pri >= 0; pri-- {
+		q := s.queues[pri]
+		if len(q) != 0 {
+			req := q[0]
+			s.queues[pri] = q[1:]
+			return req
+		}
+	}
+	return nil
+}
+
+func (s *Session) run() {
+	defer close(s.done)
+	for {
+		s.mu.Lock()
+		for !s.closed && s.pending() == 0 {
+			s.cond.Wait()
+		}
+		req := s.next()
+		s.mu.Unlock()
+		if req == nil {
+			return
+		}
+		s.perform(req)
+	}
+}
+
+// perform calls the request's op, recovering from a panic
+// so that it is returned to the caller as an error
+// and the session continues with the next request.
+func (s *Session) perform(req *request) {
+	defer close(req.done)
+	defer func() {
+		if r := recover(); r != nil {
+			s.pump.SetError(nil)
+			req.err = fmt.Errorf("pump session request panicked: %v", r)
+		}
+	}()
+	req.err = s.pump.try(func() { req.op(s.pump) })
+}
+
+// Close waits for queued requests to be performed,
+// then closes communication with the pump.
+func (s *Session) Close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	s.cond.Broadcast()
+	s.mu.Unlock()
+	<-s.done
+	s.pump.Close()
+}
+
+// Suspend suspends or resumes the pump.
+func (s *Session) Suspend(yes bool) error {
+	return s.Do(HighPriority, func(pump *Pump) { pump.Suspend(yes) })
+}
+
+// SetAbsoluteTempBasal sets a temporary basal with the given absolute rate and duration.
+func (s *Session) SetAbsoluteTempBasal(duration time.Duration, rate Insulin) error {
+	return s.Do(HighPriority, func(pump *Pump) { pump.SetAbsoluteTempBasal(duration, rate) })
+}
+
+// SetPercentTempBasal sets a temporary basal with the given percent rate and duration.
+func (s *Session) SetPercentTempBasal(duration time.Duration, percent int) error {
+	return s.Do(HighPriority, func(pump *Pump) { pump.SetPercentTempBasal(duration, percent) })
+}
+
+// Bolus delivers the given amount of insulin as a bolus.
+func (s *Session) Bolus(amount Insulin) error {
+	return s.Do(HighPriority, func(pump *Pump) { pump.Bolus(amount) })
+}
+
+// Status returns the pump's status.
+func (s *Session) Status() (StatusInfo, error) {
+	var v StatusInfo
+	err := s.Do(NormalPriority, func(pump *Pump) { v = pump.Status() })
+	return v, err
+}
+
+// Reservoir returns the amount of insulin remaining.
+func (s *Session) Reservoir() (Insulin, error) {
+	var v Insulin
+	err := s.Do(NormalPriority, func(pump *Pump) { v = pump.Reservoir() })
+	return v, err
+}
+
+// TempBasal returns the pump's current temporary basal setting.
+func (s *Session) TempBasal() (TempBasalInfo, error) {
+	var v TempBasalInfo
+	err := s.Do(NormalPriority, func(pump *Pump) { v = pump.TempBasal() })
+	return v, err
+}
+
+// HistoryPage downloads the given history page.
+func (s *Session) HistoryPage(page int) ([]byte, error) {
+	var v []byte
+	err := s.Do(LowPriority, func(pump *Pump) { v = pump.HistoryPage(page) })
+	return v, err
+}
+
+// History returns the history records since the specified time.
+// Each page is downloaded as a separate low-priority request.
+// Records retrieved before an error occurred are returned along with it.
+func (s *Session) History(since time.Time) (History, error) {
+	var results History
+	err := s.HistoryPages(since, func(_ int, records History) error {
+		results = append(results, records...)
+		return nil
+	})
+	return results, err
+}
+
+// HistoryPages downloads history pages, most recent first,
+// until a record that did not occur after the specified time is found.
+// Each page is downloaded as a separate low-priority request,
+// and fn is called with the records after the cutoff in that page.
+// If fn returns an error, no more pages are downloaded and the error is returned.
+func (s *Session) HistoryPages(since time.Time, fn func(page int, records History) error) error {
+	return s.HistoryPagesContext(context.Background(), since, fn)
+}
+
+// HistoryPagesContext is like HistoryPages but stops when ctx is done.
+func (s *Session) HistoryPagesContext(ctx context.Context, since time.Time, fn func(page int, records History) error) error {
+	var count int
+	var family Family
+	err := s.DoContext(ctx, LowPriority, func(pump *Pump) {
+		count = pump.HistoryPageCount()
+		if pump.Error() == nil {
+			family = pump.Family()
+		}
+	})
+	if err != nil {
+		return err
+	}
+	for page := 0; page < count; page++ {
+		var data []byte
+		err := s.DoContext(ctx, LowPriority, func(pump *Pump) { data = pump.HistoryPage(page) })
+		if err != nil {
+			return err
+		}
+		records, decodeErr := DecodeHistory(data, family)
+		i := findSince(records, since)
+		err = fn(page, records[:i])
+		if err != nil {
+			return err
+		}
+		if decodeErr != nil {
+			return decodeErr
+		}
+		if i < len(records) {
+			s.pump.logScanStop("pump history", page, records[i].Time)
+			break
+		}
+	}
+	return nil
+}
+
+// CGMHistoryPages downloads glucose pages, most recent first,
+// until a record that did not occur after the specified time is found.
+// Each page is downloaded as a separate low-priority request,
+// and fn is called with the records after the cutoff in that page.
+// If fn returns an error, no more pages are downloaded and the error is returned.
+func (s *Session) CGMHistoryPages(since time.Time, fn func(page int, records CGMHistory) error) error {
+	return s.CGMHistoryPagesContext(context.Background(), since, fn)
+}
+
+// CGMHistoryPagesContext is like CGMHistoryPages but stops when ctx is done.
+func (s *Session) CGMHistoryPagesContext(ctx context.Context, since time.Time, fn func(page int, records CGMHistory) error) error {
+	var n int
+	err := s.DoContext(ctx, LowPriority, func(pump *Pump) { n = pump.CGMCurrentGlucosePage() })
+	if err != nil {
+		return err
+	}
+	m := n - MaxGlucosePages + 1
+	if m < 0 {
+		m = 0
+	}
+	var last time.Time
+	wroteTimestamp := false
+	for page := n; page >= m; page-- {
+		var data []byte
+		err = s.DoContext(ctx, LowPriority, func(pump *Pump) { data = pump.GlucosePage(page) })
+		if err != nil {
+			return err
+		}
+		records, t, decodeErr := DecodeCGMHistory(data, last)
+		if decodeErr == ErrorNeedsTimestamp && page == n && !wroteTimestamp {
+			// This is only tried once, for the first page.
+			err = s.DoContext(ctx, NormalPriority, func(pump *Pump) { wroteTimestamp = pump.writeTimestamp(page) })
+			if err != nil {
+				return err
+			}
+			if wroteTimestamp {
+				page = n + 1
+				continue
+			}
+		}
+		i := findCGMSince(records, since)
+		err = fn(page, records[:i])
+		if err != nil {
+			return err
+		}
+		if decodeErr != nil {
+			return decodeErr
+		}
+		if i < len(records) {
+			s.pump.logScanStop("CGM history", page, records[i].Time)
+			break
+		}
+		last = t
+	}
+	return nil
+}
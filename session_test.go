@@ -0,0 +1,136 @@
+package medtronic
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/thecubic/medtronic/simulator"
+)
+
+func TestSessionPriority(t *testing.T) {
+	s := NewSession(simulatedPump(t, simulator.DefaultConfig(testPumpID, "523")))
+	defer s.Close()
+	// Block the session until all the requests below are queued.
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go func() {
+		_ = s.Do(NormalPriority, func(*Pump) {
+			close(started)
+			<-release
+		})
+	}()
+	<-started
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	requests := []struct {
+		name string
+		pri  Priority
+	}{
+		{"page 0", LowPriority},
+		{"status", NormalPriority},
+		{"page 1", LowPriority},
+		{"suspend", HighPriority},
+		{"temp basal", HighPriority},
+	}
+	for i, r := range requests {
+		wg.Add(1)
+		go func(name string, pri Priority) {
+			defer wg.Done()
+			_ = s.Do(pri, func(*Pump) {
+				mu.Lock()
+				order = append(order, name)
+				mu.Unlock()
+			})
+		}(r.name, r.pri)
+		// Wait for each request to be queued, so the order within a priority is known.
+		for s.Pending() != i+1 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	close(release)
+	wg.Wait()
+	want := []string{"suspend", "temp basal", "status", "page 0", "page 1"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("requests performed in order %v, want %v", order, want)
+	}
+}
+
+func TestSessionConcurrency(t *testing.T) {
+	config := simulator.DefaultConfig(testPumpID, "554")
+	s := NewSession(simulatedPump(t, config))
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			r, err := s.Reservoir()
+			if err != nil {
+				t.Error(err)
+			} else if r != Insulin(config.Reservoir) {
+				t.Errorf("Reservoir() == %v, want %v", r, Insulin(config.Reservoir))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := s.History(time.Now().Add(-time.Hour))
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	if err := s.SetAbsoluteTempBasal(30*time.Minute, 500); err != nil {
+		t.Error(err)
+	}
+	wg.Wait()
+	tb, err := s.TempBasal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tb.Rate == nil || *tb.Rate != 500 {
+		t.Errorf("TempBasal() == %+v", tb)
+	}
+	s.Close()
+	if err := s.Suspend(true); err != ErrSessionClosed {
+		t.Errorf("Suspend after Close returned %v, want %v", err, ErrSessionClosed)
+	}
+}
+
+func TestSessionPanic(t *testing.T) {
+	s := NewSession(simulatedPump(t, simulator.DefaultConfig(testPumpID, "523")))
+	defer s.Close()
+	err := s.Do(NormalPriority, func(*Pump) { panic("test") })
+	if err == nil {
+		t.Errorf("Do() of panicking op returned no error")
+	}
+	var r Insulin
+	err = s.Do(NormalPriority, func(pump *Pump) { r = pump.Reservoir() })
+	if err != nil || r == 0 {
+		t.Errorf("Do() after panic == %v, %v", r, err)
+	}
+}
+
+func TestSessionHistoryContext(t *testing.T) {
+	s := NewSession(simulatedPump(t, simulator.DefaultConfig(testPumpID, "523")))
+	defer s.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	called := false
+	err := s.HistoryPagesContext(ctx, time.Time{}, func(int, History) error {
+		called = true
+		return nil
+	})
+	if err != context.Canceled {
+		t.Errorf("HistoryPagesContext returned %v, want %v", err, context.Canceled)
+	}
+	if called {
+		t.Errorf("history page downloaded after context was canceled")
+	}
+	_, err = s.Status()
+	if err != nil {
+		t.Errorf("Status after canceled request returned %v", err)
+	}
+}
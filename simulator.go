@@ -0,0 +1,7 @@
+// +build simulator
+
+package medtronic
+
+import "github.com/thecubic/medtronic/simulator"
+
+var radioInterface = simulator.Open
@@ -0,0 +1,601 @@
+package simulator
+
+import (
+	"time"
+
+	"github.com/thecubic/medtronic/packet"
+)
+
+// Command codes, as defined in the medtronic package.
+const (
+	ack                  = 0x06
+	nak                  = 0x15
+	cgmWriteTimestamp    = 0x28
+	setBasalPatternA     = 0x30
+	setBasalPatternB     = 0x31
+	setClock             = 0x40
+	setMaxBolus          = 0x41
+	bolus                = 0x42
+	selectBasalPattern   = 0x4A
+	setAbsoluteTempBasal = 0x4C
+	suspend              = 0x4D
+	button               = 0x5B
+	wakeup               = 0x5D
+	setPercentTempBasal  = 0x69
+	setMaxBasal          = 0x6E
+	setBasalRates        = 0x6F
+	clock                = 0x70
+	pumpID               = 0x71
+	battery              = 0x72
+	reservoir            = 0x73
+	firmwareVersion      = 0x74
+	historyPage          = 0x80
+	carbUnits            = 0x88
+	glucoseUnits         = 0x89
+	carbRatios           = 0x8A
+	insulinSensitivities = 0x8B
+	glucoseTargets512    = 0x8C
+	model                = 0x8D
+	settings512          = 0x91
+	basalRates           = 0x92
+	basalPatternA        = 0x93
+	basalPatternB        = 0x94
+	tempBasal            = 0x98
+	glucosePage          = 0x9A
+	isigPage             = 0x9B
+	calibrationFactor    = 0x9C
+	historyPageCount     = 0x9D
+	glucoseTargets       = 0x9F
+	settings             = 0xC0
+	cgmPageCount         = 0xCD
+	status               = 0xCE
+	vcntrPage            = 0xD5
+)
+
+// Pump error codes, as defined in the medtronic package.
+const (
+	commandRefused           = 0x08
+	maxSettingExceeded       = 0x09
+	bolusInProgress          = 0x0C
+	invalidHistoryPageNumber = 0x0D
+)
+
+const (
+	shortPacketLength = 6 // excluding CRC byte
+	payloadLength     = 64
+	fragmentLength    = payloadLength + 1 // including sequence number
+	doneBit           = 1 << 7
+	basalDataLength   = 192
+)
+
+type handler struct {
+	params   bool // command is followed by a parameter packet
+	extended bool // parameters span a sequence of packets
+	fn       func(*Pump, []byte) []byte
+}
+
+var handlers = map[byte]handler{
+	wakeup:               {false, false, (*Pump).accept},
+	button:               {true, false, (*Pump).accept},
+	cgmWriteTimestamp:    {false, false, (*Pump).accept},
+	model:                {false, false, (*Pump).model},
+	pumpID:               {false, false, (*Pump).pumpID},
+	firmwareVersion:      {false, false, (*Pump).firmwareVersion},
+	clock:                {false, false, (*Pump).clock},
+	setClock:             {true, false, (*Pump).setClock},
+	battery:              {false, false, (*Pump).battery},
+	reservoir:            {false, false, (*Pump).reservoir},
+	status:               {false, false, (*Pump).status},
+	suspend:              {true, false, (*Pump).suspend},
+	bolus:                {true, false, (*Pump).bolus},
+	tempBasal:            {false, false, (*Pump).tempBasal},
+	setAbsoluteTempBasal: {true, false, (*Pump).setAbsoluteTempBasal},
+	setPercentTempBasal:  {true, false, (*Pump).setPercentTempBasal},
+	settings:             {false, false, (*Pump).settings},
+	settings512:          {false, false, (*Pump).settings},
+	setMaxBolus:          {true, false, (*Pump).setMaxBolus},
+	setMaxBasal:          {true, false, (*Pump).setMaxBasal},
+	carbUnits:            {false, false, (*Pump).carbUnits},
+	glucoseUnits:         {false, false, (*Pump).glucoseUnits},
+	carbRatios:           {false, false, (*Pump).carbRatios},
+	insulinSensitivities: {false, false, (*Pump).insulinSensitivities},
+	glucoseTargets:       {false, false, (*Pump).glucoseTargets},
+	glucoseTargets512:    {false, false, (*Pump).glucoseTargets},
+	basalRates:           {false, false, (*Pump).basalRates},
+	basalPatternA:        {false, false, (*Pump).basalPatternA},
+	basalPatternB:        {false, false, (*Pump).basalPatternB},
+	setBasalRates:        {true, true, (*Pump).setBasalRates},
+	setBasalPatternA:     {true, true, (*Pump).setBasalPatternA},
+	setBasalPatternB:     {true, true, (*Pump).setBasalPatternB},
+	selectBasalPattern:   {true, false, (*Pump).selectBasalPattern},
+	historyPageCount:     {false, false, (*Pump).historyPageCount},
+	historyPage:          {true, false, (*Pump).historyPage},
+	cgmPageCount:         {false, false, (*Pump).cgmPageCount},
+	glucosePage:          {true, false, (*Pump).glucosePage},
+	isigPage:             {true, false, (*Pump).isigPage},
+	vcntrPage:            {true, false, (*Pump).vcntrPage},
+	calibrationFactor:    {false, false, (*Pump).calibrationFactor},
+}
+
+// handle processes a decoded packet and returns the unencoded reply, if any.
+// Packets are of the form:
+//   device type (0xA7)
+//   3 bytes of pump ID
+//   command code
+//   length of parameters (or fragment number)
+//   parameters (long packets only)
+func (sim *Pump) handle(data []byte) []byte {
+	cmd := data[4]
+	if sim.config.Asleep {
+		if cmd != wakeup {
+			return nil
+		}
+		sim.config.Asleep = false
+		return sim.ack()
+	}
+	switch cmd {
+	case ack:
+		return sim.nextFragment()
+	case nak:
+		return sim.lastFrag
+	}
+	h, found := handlers[cmd]
+	if len(data) == shortPacketLength {
+		sim.command = 0
+		sim.fragments = nil
+		sim.lastFrag = nil
+		if !found {
+			return sim.nak(commandRefused)
+		}
+		if h.params {
+			sim.command = cmd
+			sim.request = nil
+			return sim.ack()
+		}
+		return h.fn(sim, nil)
+	}
+	// This is a parameter packet for the command in progress.
+	if !found || cmd != sim.command {
+		sim.command = 0
+		return sim.nak(commandRefused)
+	}
+	n := int(data[5])
+	params := data[6:]
+	if !h.extended {
+		sim.command = 0
+		if n > len(params) {
+			n = len(params)
+		}
+		return h.fn(sim, params[:n])
+	}
+	if n&doneBit == 0 {
+		sim.request = append(sim.request, params...)
+		return sim.ack()
+	}
+	sim.command = 0
+	return h.fn(sim, sim.request)
+}
+
+func (sim *Pump) header(cmd byte) []byte {
+	return []byte{packet.Pump, sim.addr[0], sim.addr[1], sim.addr[2], cmd}
+}
+
+// reply constructs a response to the given command, zero-padded to the full payload length.
+func (sim *Pump) reply(cmd byte, body []byte) []byte {
+	r := append(sim.header(cmd), body...)
+	for len(r) < 5+fragmentLength {
+		r = append(r, 0)
+	}
+	return r
+}
+
+func (sim *Pump) ack() []byte {
+	return append(sim.header(ack), 0)
+}
+
+// accept acknowledges a command that has no effect on the simulated pump.
+func (sim *Pump) accept(_ []byte) []byte {
+	return sim.ack()
+}
+
+func (sim *Pump) nak(code byte) []byte {
+	return append(sim.header(nak), code)
+}
+
+// extendedResponse splits data into a sequence of numbered fragments,
+// returns the first, and saves the rest to be sent as each is acknowledged.
+func (sim *Pump) extendedResponse(cmd byte, data []byte) []byte {
+	sim.fragments = nil
+	for i, seq := 0, 1; i < len(data); i, seq = i+payloadLength, seq+1 {
+		j := i + payloadLength
+		b := byte(seq)
+		if j >= len(data) {
+			j = len(data)
+			b |= doneBit
+		}
+		frag := sim.reply(cmd, append([]byte{b}, data[i:j]...))
+		sim.fragments = append(sim.fragments, frag)
+	}
+	return sim.nextFragment()
+}
+
+func (sim *Pump) nextFragment() []byte {
+	if len(sim.fragments) == 0 {
+		return nil
+	}
+	sim.lastFrag = sim.fragments[0]
+	sim.fragments = sim.fragments[1:]
+	return sim.lastFrag
+}
+
+// now returns the time according to the pump's clock.
+func (sim *Pump) now() time.Time {
+	return time.Now().Add(sim.config.ClockOffset)
+}
+
+// strokes returns the number of strokes for the given amount of insulin
+// when delivered by this pump family.
+func (sim *Pump) strokes(amount int) int {
+	return amount / sim.milliUnitsPerStroke()
+}
+
+func (sim *Pump) milliUnitsPerStroke() int {
+	if sim.family <= 22 {
+		return 100
+	}
+	return 25
+}
+
+func lengthPrefixed(s string) []byte {
+	return append([]byte{byte(len(s))}, s...)
+}
+
+func (sim *Pump) model(_ []byte) []byte {
+	m := sim.config.Model
+	body := append([]byte{byte(len(m) + 1)}, lengthPrefixed(m)...)
+	return sim.reply(model, body)
+}
+
+func (sim *Pump) pumpID(_ []byte) []byte {
+	return sim.reply(pumpID, lengthPrefixed(sim.config.ID))
+}
+
+func (sim *Pump) firmwareVersion(_ []byte) []byte {
+	return sim.reply(firmwareVersion, lengthPrefixed(sim.config.Firmware))
+}
+
+func (sim *Pump) clock(_ []byte) []byte {
+	t := sim.now()
+	y := t.Year()
+	return sim.reply(clock, []byte{
+		7,
+		byte(t.Hour()),
+		byte(t.Minute()),
+		byte(t.Second()),
+		byte(y >> 8), byte(y),
+		byte(t.Month()),
+		byte(t.Day()),
+	})
+}
+
+func (sim *Pump) setClock(params []byte) []byte {
+	if len(params) < 7 {
+		return sim.nak(commandRefused)
+	}
+	year := int(params[3])<<8 | int(params[4])
+	t := time.Date(year, time.Month(params[5]), int(params[6]),
+		int(params[0]), int(params[1]), int(params[2]), 0, time.Local)
+	old := sim.now()
+	sim.config.ClockOffset = time.Until(t)
+	sim.addRecord(changeTimeRecord(old))
+	sim.addRecord(newTimeRecord(t))
+	return sim.ack()
+}
+
+func (sim *Pump) battery(_ []byte) []byte {
+	v := sim.config.Battery / 10
+	low := byte(0)
+	if sim.config.LowBattery {
+		low = 1
+	}
+	return sim.reply(battery, []byte{3, low, byte(v >> 8), byte(v)})
+}
+
+func (sim *Pump) reservoir(_ []byte) []byte {
+	n := sim.strokes(sim.config.Reservoir)
+	if sim.family <= 22 {
+		return sim.reply(reservoir, []byte{2, byte(n >> 8), byte(n)})
+	}
+	return sim.reply(reservoir, []byte{4, 0, 0, byte(n >> 8), byte(n)})
+}
+
+func boolByte(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (sim *Pump) status(_ []byte) []byte {
+	c := sim.config
+	return sim.reply(status, []byte{3, c.StatusCode, boolByte(c.Bolusing), boolByte(c.Suspended)})
+}
+
+func (sim *Pump) suspend(params []byte) []byte {
+	if len(params) < 1 {
+		return sim.nak(commandRefused)
+	}
+	yes := params[0] != 0
+	if yes == sim.config.Suspended {
+		return sim.ack()
+	}
+	sim.config.Suspended = yes
+	sim.addRecord(suspendRecord(yes, sim.now()))
+	return sim.ack()
+}
+
+func (sim *Pump) bolus(params []byte) []byte {
+	var n int
+	if sim.family <= 22 {
+		if len(params) < 1 {
+			return sim.nak(commandRefused)
+		}
+		n = int(params[0])
+	} else {
+		if len(params) < 2 {
+			return sim.nak(commandRefused)
+		}
+		n = int(params[0])<<8 | int(params[1])
+	}
+	amount := n * sim.milliUnitsPerStroke()
+	switch {
+	case sim.config.Suspended:
+		return sim.nak(commandRefused)
+	case sim.config.Bolusing:
+		return sim.nak(bolusInProgress)
+	case amount > sim.config.MaxBolus:
+		return sim.nak(maxSettingExceeded)
+	case amount > sim.config.Reservoir:
+		return sim.nak(commandRefused)
+	}
+	sim.config.Reservoir -= amount
+	sim.addRecord(sim.bolusRecord(amount, sim.now()))
+	return sim.ack()
+}
+
+// activeTempBasal returns the temp basal in effect and its remaining duration.
+func (sim *Pump) activeTempBasal() (TempBasal, time.Duration) {
+	tb := sim.config.TempBasal
+	d := time.Until(tb.Expires)
+	if d <= 0 {
+		return TempBasal{Percent: sim.config.TempBasalType == 1}, 0
+	}
+	return tb, d
+}
+
+func (sim *Pump) tempBasal(_ []byte) []byte {
+	tb, d := sim.activeTempBasal()
+	min := int((d + time.Minute - 1) / time.Minute)
+	body := []byte{6, 0, 0, 0, 0, byte(min >> 8), byte(min)}
+	if tb.Percent {
+		body[1] = 1
+		body[2] = byte(tb.Rate)
+	} else {
+		r := tb.Rate / 25
+		body[3] = byte(r >> 8)
+		body[4] = byte(r)
+	}
+	return sim.reply(tempBasal, body)
+}
+
+func (sim *Pump) startTempBasal(tb TempBasal, halfHours byte) []byte {
+	if sim.config.Suspended {
+		return sim.nak(commandRefused)
+	}
+	d := time.Duration(halfHours) * 30 * time.Minute
+	tb.Expires = time.Now().Add(d)
+	sim.config.TempBasal = tb
+	t := sim.now()
+	sim.addRecord(tempBasalRecord(tb, t))
+	sim.addRecord(tempBasalDurationRecord(halfHours, t))
+	return sim.ack()
+}
+
+func (sim *Pump) setAbsoluteTempBasal(params []byte) []byte {
+	if len(params) < 3 {
+		return sim.nak(commandRefused)
+	}
+	rate := (int(params[0])<<8 | int(params[1])) * 25
+	if rate > sim.config.MaxBasal {
+		return sim.nak(maxSettingExceeded)
+	}
+	return sim.startTempBasal(TempBasal{Rate: rate}, params[2])
+}
+
+func (sim *Pump) setPercentTempBasal(params []byte) []byte {
+	if len(params) < 2 {
+		return sim.nak(commandRefused)
+	}
+	if params[0] > 200 {
+		return sim.nak(maxSettingExceeded)
+	}
+	return sim.startTempBasal(TempBasal{Percent: true, Rate: int(params[0])}, params[1])
+}
+
+func (sim *Pump) settings(_ []byte) []byte {
+	c := sim.config
+	var body []byte
+	var maxBolus, maxBasal int
+	switch {
+	case sim.family <= 12:
+		body = make([]byte, 19)
+		maxBolus, maxBasal = 6, 7
+	case sim.family <= 22:
+		body = make([]byte, 22)
+		maxBolus, maxBasal = 6, 7
+	default:
+		body = make([]byte, 26)
+		maxBolus, maxBasal = 7, 8
+	}
+	body[0] = byte(len(body) - 1)
+	body[1] = byte(c.AutoOff / time.Hour)
+	body[maxBolus] = byte(c.MaxBolus / 100)
+	r := c.MaxBasal / 25
+	body[maxBasal] = byte(r >> 8)
+	body[maxBasal+1] = byte(r)
+	body[12] = byte(c.SelectedPattern)
+	body[13] = boolByte(c.RFEnabled)
+	body[14] = c.TempBasalType
+	if sim.family > 12 {
+		body[18] = byte(c.InsulinAction / time.Hour)
+	}
+	cmd := settings
+	if sim.family <= 12 {
+		cmd = settings512
+	}
+	return sim.reply(byte(cmd), body)
+}
+
+func (sim *Pump) setMaxBolus(params []byte) []byte {
+	if len(params) < 1 {
+		return sim.nak(commandRefused)
+	}
+	sim.config.MaxBolus = int(params[0]) * 100
+	sim.addRecord(maxRecord(maxBolusRecordType, sim.config.MaxBolus, sim.now()))
+	return sim.ack()
+}
+
+func (sim *Pump) setMaxBasal(params []byte) []byte {
+	if len(params) < 2 {
+		return sim.nak(commandRefused)
+	}
+	sim.config.MaxBasal = (int(params[0])<<8 | int(params[1])) * 25
+	sim.addRecord(maxRecord(maxBasalRecordType, sim.config.MaxBasal, sim.now()))
+	return sim.ack()
+}
+
+func (sim *Pump) carbUnits(_ []byte) []byte {
+	return sim.reply(carbUnits, []byte{1, sim.config.CarbUnits})
+}
+
+func (sim *Pump) glucoseUnits(_ []byte) []byte {
+	return sim.reply(glucoseUnits, []byte{1, sim.config.GlucoseUnits})
+}
+
+func halfHours(d time.Duration) byte {
+	return byte(d / (30 * time.Minute))
+}
+
+func (sim *Pump) carbRatios(_ []byte) []byte {
+	var entries []byte
+	for _, r := range sim.config.CarbRatios {
+		if sim.family <= 22 {
+			v := r.Ratio / 10
+			if sim.config.CarbUnits == 2 {
+				v = r.Ratio / 100
+			}
+			entries = append(entries, halfHours(r.Start), byte(v))
+		} else {
+			entries = append(entries, halfHours(r.Start), byte(r.Ratio>>8), byte(r.Ratio))
+		}
+	}
+	body := []byte{byte(len(entries) + 1), sim.config.CarbUnits}
+	if sim.family > 22 {
+		body = append(body, 0)
+	}
+	return sim.reply(carbRatios, append(body, entries...))
+}
+
+func (sim *Pump) insulinSensitivities(_ []byte) []byte {
+	var entries []byte
+	for _, s := range sim.config.Sensitivities {
+		hi := byte(s.Value>>8) & 0x1
+		entries = append(entries, halfHours(s.Start)|hi<<6, byte(s.Value))
+	}
+	body := []byte{byte(len(entries) + 1), sim.config.GlucoseUnits}
+	return sim.reply(insulinSensitivities, append(body, entries...))
+}
+
+func (sim *Pump) glucoseTargets(_ []byte) []byte {
+	var entries []byte
+	for _, t := range sim.config.Targets {
+		entries = append(entries, halfHours(t.Start), byte(t.Low))
+		if sim.family > 12 {
+			entries = append(entries, byte(t.High))
+		}
+	}
+	body := []byte{byte(len(entries) + 1), sim.config.GlucoseUnits}
+	cmd := glucoseTargets
+	if sim.family <= 12 {
+		cmd = glucoseTargets512
+	}
+	return sim.reply(byte(cmd), append(body, entries...))
+}
+
+func encodeBasalRates(sched []BasalRate) []byte {
+	data := make([]byte, 0, basalDataLength)
+	for _, r := range sched {
+		n := r.Rate / 25
+		data = append(data, byte(n), byte(n>>8), halfHours(r.Start))
+	}
+	for len(data) < basalDataLength {
+		data = append(data, 0)
+	}
+	return data
+}
+
+func decodeBasalRates(data []byte) []BasalRate {
+	var sched []BasalRate
+	for i := 0; i+2 < len(data); i += 3 {
+		rate := (int(data[i+1])<<8 | int(data[i])) * 25
+		t := data[i+2]
+		if i > 0 && rate == 0 && t == 0 {
+			break
+		}
+		sched = append(sched, BasalRate{
+			Start: time.Duration(t) * 30 * time.Minute,
+			Rate:  rate,
+		})
+	}
+	return sched
+}
+
+func (sim *Pump) basalRates(_ []byte) []byte {
+	return sim.extendedResponse(basalRates, encodeBasalRates(sim.config.BasalRates))
+}
+
+func (sim *Pump) basalPatternA(_ []byte) []byte {
+	return sim.extendedResponse(basalPatternA, encodeBasalRates(sim.config.BasalPatternA))
+}
+
+func (sim *Pump) basalPatternB(_ []byte) []byte {
+	return sim.extendedResponse(basalPatternB, encodeBasalRates(sim.config.BasalPatternB))
+}
+
+func (sim *Pump) setBasalRates(params []byte) []byte {
+	sim.config.BasalRates = decodeBasalRates(params)
+	return sim.ack()
+}
+
+func (sim *Pump) setBasalPatternA(params []byte) []byte {
+	sim.config.BasalPatternA = decodeBasalRates(params)
+	return sim.ack()
+}
+
+func (sim *Pump) setBasalPatternB(params []byte) []byte {
+	sim.config.BasalPatternB = decodeBasalRates(params)
+	return sim.ack()
+}
+
+func (sim *Pump) selectBasalPattern(params []byte) []byte {
+	if len(params) < 1 || params[0] > 2 {
+		return sim.nak(commandRefused)
+	}
+	sim.config.SelectedPattern = int(params[0])
+	return sim.ack()
+}
+
+func (sim *Pump) calibrationFactor(_ []byte) []byte {
+	f := sim.config.CalibrationFactor
+	return sim.reply(calibrationFactor, []byte{2, byte(f >> 8), byte(f)})
+}
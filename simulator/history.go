@@ -0,0 +1,194 @@
+package simulator
+
+import (
+	"sort"
+	"time"
+
+	"github.com/thecubic/medtronic/packet"
+)
+
+const (
+	maxHistoryPages = 36
+	historyPageSize = 1022 // excluding CRC-16
+	isigPageSize    = 2044 // excluding CRC-16
+)
+
+// History record types generated by the simulator.
+const (
+	bolusRecordType             = 0x01
+	tempBasalDurationRecordType = 0x16
+	changeTimeRecordType        = 0x17
+	newTimeRecordType           = 0x18
+	suspendRecordType           = 0x1E
+	resumeRecordType            = 0x1F
+	maxBolusRecordType          = 0x24
+	maxBasalRecordType          = 0x2C
+	tempBasalRecordType         = 0x33
+)
+
+// encodeTime encodes a 5-byte timestamp for a pump history record.
+// The 4-bit month value is encoded in the high 2 bits of the first 2 bytes.
+func encodeTime(t time.Time) []byte {
+	month := byte(t.Month())
+	return []byte{
+		byte(t.Second()) | (month>>2)<<6,
+		byte(t.Minute()) | (month&0x3)<<6,
+		byte(t.Hour()),
+		byte(t.Day()),
+		byte(t.Year() - 2000),
+	}
+}
+
+func baseRecord(recordType byte, value byte, t time.Time) []byte {
+	return append([]byte{recordType, value}, encodeTime(t)...)
+}
+
+func changeTimeRecord(t time.Time) []byte {
+	return baseRecord(changeTimeRecordType, 0, t)
+}
+
+func newTimeRecord(t time.Time) []byte {
+	return baseRecord(newTimeRecordType, 0, t)
+}
+
+func suspendRecord(yes bool, t time.Time) []byte {
+	if yes {
+		return baseRecord(suspendRecordType, 0, t)
+	}
+	return baseRecord(resumeRecordType, 0, t)
+}
+
+func maxRecord(recordType byte, amount int, t time.Time) []byte {
+	return baseRecord(recordType, byte(amount/25), t)
+}
+
+func (sim *Pump) bolusRecord(amount int, t time.Time) []byte {
+	n := sim.strokes(amount)
+	if sim.family <= 22 {
+		r := []byte{bolusRecordType, byte(n), byte(n), 0}
+		return append(r, encodeTime(t)...)
+	}
+	r := []byte{bolusRecordType, byte(n >> 8), byte(n), byte(n >> 8), byte(n), 0, 0, 0}
+	return append(r, encodeTime(t)...)
+}
+
+func tempBasalRecord(tb TempBasal, t time.Time) []byte {
+	if tb.Percent {
+		return append(baseRecord(tempBasalRecordType, byte(tb.Rate), t), 1<<3)
+	}
+	n := tb.Rate / 25
+	return append(baseRecord(tempBasalRecordType, byte(n), t), byte(n>>8)&0x7)
+}
+
+func tempBasalDurationRecord(halfHours byte, t time.Time) []byte {
+	return baseRecord(tempBasalDurationRecordType, halfHours, t)
+}
+
+// addRecord appends a history record to the current page,
+// starting a new page when there is no room left.
+func (sim *Pump) addRecord(r []byte) {
+	if sim.current == nil || len(sim.current)+len(r) > historyPageSize {
+		if sim.current != nil {
+			sim.config.HistoryPages = append([][]byte{sim.current}, sim.config.HistoryPages...)
+		}
+		if len(sim.config.HistoryPages) >= maxHistoryPages {
+			sim.config.HistoryPages = sim.config.HistoryPages[:maxHistoryPages-1]
+		}
+		sim.current = make([]byte, 0, historyPageSize)
+	}
+	sim.current = append(sim.current, r...)
+}
+
+// historyPages returns the history pages, most recent first.
+func (sim *Pump) historyPages() [][]byte {
+	if sim.current == nil {
+		return sim.config.HistoryPages
+	}
+	return append([][]byte{sim.current}, sim.config.HistoryPages...)
+}
+
+func (sim *Pump) historyPageCount(_ []byte) []byte {
+	if sim.family <= 12 {
+		return sim.nak(commandRefused)
+	}
+	n := len(sim.historyPages())
+	return sim.reply(historyPageCount, []byte{4, byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)})
+}
+
+// pageWithCRC zero-pads a page and appends its CRC-16.
+// In a 2048-byte ISIG page, the CRC-16 is stored in the last 4 bytes: [high 0 low 0]
+func pageWithCRC(data []byte, size int) []byte {
+	page := make([]byte, size, size+4)
+	copy(page, data)
+	crc := packet.CRC16(page)
+	if size == isigPageSize {
+		return append(page, byte(crc>>8), 0, byte(crc), 0)
+	}
+	return append(page, byte(crc>>8), byte(crc))
+}
+
+func (sim *Pump) historyPage(params []byte) []byte {
+	pages := sim.historyPages()
+	if len(pages) == 0 {
+		// A pump with no history still has an empty current page.
+		pages = [][]byte{nil}
+	}
+	if len(params) < 1 || int(params[0]) >= len(pages) {
+		return sim.nak(invalidHistoryPageNumber)
+	}
+	page := pageWithCRC(pages[params[0]], historyPageSize)
+	return sim.extendedResponse(historyPage, page)
+}
+
+func pageNumber(params []byte) int {
+	if len(params) < 4 {
+		return -1
+	}
+	return int(params[0])<<24 | int(params[1])<<16 | int(params[2])<<8 | int(params[3])
+}
+
+func (sim *Pump) currentGlucosePage() int {
+	var pages []int
+	for n := range sim.config.GlucosePages {
+		pages = append(pages, n)
+	}
+	if len(pages) == 0 {
+		return 0
+	}
+	sort.Ints(pages)
+	return pages[len(pages)-1]
+}
+
+func (sim *Pump) cgmPageCount(_ []byte) []byte {
+	n := sim.currentGlucosePage()
+	body := make([]byte, 13)
+	body[0] = 12
+	body[1] = byte(n >> 24)
+	body[2] = byte(n >> 16)
+	body[3] = byte(n >> 8)
+	body[4] = byte(n)
+	return sim.reply(cgmPageCount, body)
+}
+
+func (sim *Pump) cgmPage(cmd byte, pages map[int][]byte, n int, size int) []byte {
+	data, found := pages[n]
+	if !found {
+		return sim.nak(invalidHistoryPageNumber)
+	}
+	return sim.extendedResponse(cmd, pageWithCRC(data, size))
+}
+
+func (sim *Pump) glucosePage(params []byte) []byte {
+	return sim.cgmPage(glucosePage, sim.config.GlucosePages, pageNumber(params), historyPageSize)
+}
+
+func (sim *Pump) isigPage(params []byte) []byte {
+	return sim.cgmPage(isigPage, sim.config.ISIGPages, pageNumber(params), isigPageSize)
+}
+
+func (sim *Pump) vcntrPage(params []byte) []byte {
+	if len(params) < 1 {
+		return sim.nak(invalidHistoryPageNumber)
+	}
+	return sim.cgmPage(vcntrPage, sim.config.VcntrPages, int(params[0]), historyPageSize)
+}
@@ -0,0 +1,413 @@
+// Package simulator implements a virtual Medtronic pump
+// behind the radio.Interface used by the medtronic package.
+//
+// Packets sent to the simulator are decoded with packet.Decode
+// and answered the way a real pump would answer them,
+// so programs can be run end to end without any radio hardware.
+package simulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ecc1/radio"
+	"github.com/thecubic/medtronic/packet"
+)
+
+const (
+	pumpEnvVar   = "MEDTRONIC_PUMP_ID"
+	modelEnvVar  = "MEDTRONIC_SIMULATOR_MODEL"
+	configEnvVar = "MEDTRONIC_SIMULATOR_CONFIG"
+
+	defaultFrequency = 916600000
+	// The pump only hears packets within this distance of its frequency.
+	bandwidth   = 150000
+	defaultRSSI = -40
+)
+
+type (
+	// BasalRate represents an entry in a basal rate schedule.
+	BasalRate struct {
+		Start time.Duration
+		Rate  int // milliUnits per hour
+	}
+
+	// CarbRatio represents an entry in a carb ratio schedule.
+	CarbRatio struct {
+		Start time.Duration
+		Ratio int // 10x grams/unit or 1000x units/exchange
+	}
+
+	// Sensitivity represents an entry in an insulin sensitivity schedule.
+	Sensitivity struct {
+		Start time.Duration
+		Value int // mg/dL or 10x mmol/L per unit
+	}
+
+	// Target represents an entry in a glucose target schedule.
+	Target struct {
+		Start time.Duration
+		Low   int // mg/dL or 10x mmol/L
+		High  int
+	}
+
+	// TempBasal represents the temporary basal in effect, if any.
+	TempBasal struct {
+		Percent bool
+		Rate    int       // milliUnits per hour, or percent if Percent is set
+		Expires time.Time // wall-clock time at which the temp basal ends
+	}
+
+	// Config specifies the state of a simulated pump.
+	// Quantities of insulin are in milliUnits,
+	// matching the representation used by the medtronic package.
+	Config struct {
+		ID        string // 6-digit pump ID
+		Model     string // "512", "522", "523", "554", etc.
+		Firmware  string
+		Frequency uint32 // frequency on which the pump listens, in Hertz
+		RSSI      int    // signal strength reported for received packets
+
+		// ClockOffset is the difference between the pump's clock and wall-clock time.
+		ClockOffset time.Duration
+		// Asleep pumps ignore everything but wakeup packets.
+		Asleep bool
+
+		Reservoir  int // milliUnits
+		Battery    int // milliVolts
+		LowBattery bool
+		StatusCode byte
+		Bolusing   bool
+		Suspended  bool
+		TempBasal  TempBasal
+
+		AutoOff         time.Duration
+		InsulinAction   time.Duration
+		MaxBolus        int
+		MaxBasal        int
+		RFEnabled       bool
+		TempBasalType   byte // 0 for absolute, 1 for percent
+		SelectedPattern int
+
+		CarbUnits     byte // 1 for grams, 2 for exchanges
+		GlucoseUnits  byte // 1 for mg/dL, 2 for mmol/L
+		BasalRates    []BasalRate
+		BasalPatternA []BasalRate
+		BasalPatternB []BasalRate
+		CarbRatios    []CarbRatio
+		Sensitivities []Sensitivity
+		Targets       []Target
+
+		// HistoryPages holds raw pump history pages without CRCs,
+		// most recent first.  Records generated by the simulator
+		// are written to a new page ahead of these.
+		HistoryPages [][]byte
+
+		// GlucosePages, ISIGPages, and VcntrPages hold raw CGM pages
+		// without CRCs, indexed by page number.
+		GlucosePages      map[int][]byte
+		ISIGPages         map[int][]byte
+		VcntrPages        map[int][]byte
+		CalibrationFactor int
+	}
+)
+
+// DefaultConfig returns the configuration of a freshly set-up pump
+// of the given model.
+func DefaultConfig(id string, model string) Config {
+	return Config{
+		ID:            id,
+		Model:         model,
+		Firmware:      "VER 2.4A1.1",
+		Frequency:     defaultFrequency,
+		RSSI:          defaultRSSI,
+		Reservoir:     150000,
+		Battery:       1450,
+		StatusCode:    3,
+		AutoOff:       0,
+		InsulinAction: 3 * time.Hour,
+		MaxBolus:      10000,
+		MaxBasal:      2000,
+		RFEnabled:     true,
+		CarbUnits:     1,
+		GlucoseUnits:  1,
+		BasalRates:    []BasalRate{{0, 1000}},
+		CarbRatios:    []CarbRatio{{0, 100}},
+		Sensitivities: []Sensitivity{{0, 40}},
+		Targets:       []Target{{0, 100, 120}},
+	}
+}
+
+// Pump is a simulated pump.  It implements radio.Interface,
+// so it can take the place of a radio module.
+type Pump struct {
+	mu     sync.Mutex
+	config Config
+	family int
+	addr   []byte
+	freq   uint32
+	err    error
+
+	// File to which the state is saved on Close, if any.
+	saveFile string
+
+	// Response to be returned by the next call to Receive.
+	pending []byte
+
+	// State of a multi-packet exchange in progress.
+	command   byte     // command awaiting its parameter packet
+	fragments [][]byte // remaining fragments of an extended response
+	lastFrag  []byte   // most recent fragment, for retransmission after a NAK
+	request   []byte   // parameters accumulated from an extended request
+
+	// Page receiving records generated by the simulator.
+	current []byte
+}
+
+// New creates a simulated pump with the given configuration.
+func New(config Config) *Pump {
+	sim := &Pump{config: config, freq: config.Frequency}
+	if sim.config.Frequency == 0 {
+		sim.config.Frequency = defaultFrequency
+	}
+	addr, err := deviceAddress(config.ID)
+	if err != nil {
+		sim.err = err
+		return sim
+	}
+	sim.addr = addr
+	sim.family, err = modelFamily(config.Model)
+	if err != nil {
+		sim.err = err
+	}
+	return sim
+}
+
+// Open creates a simulated pump with the same signature as the radio
+// drivers' Open functions, so it can be selected with a build tag.
+// The pump ID is taken from the MEDTRONIC_PUMP_ID environment variable
+// and the model from MEDTRONIC_SIMULATOR_MODEL (default 523).
+// If MEDTRONIC_SIMULATOR_CONFIG names a JSON file, the configuration
+// is read from it and the pump's state is written back to it on Close.
+func Open() radio.Interface {
+	file := os.Getenv(configEnvVar)
+	if file != "" {
+		sim, err := Load(file)
+		if err == nil || !os.IsNotExist(err) {
+			return sim
+		}
+	}
+	model := os.Getenv(modelEnvVar)
+	if model == "" {
+		model = "523"
+	}
+	sim := New(DefaultConfig(os.Getenv(pumpEnvVar), model))
+	sim.saveFile = file
+	return sim
+}
+
+// Load creates a simulated pump from a JSON configuration file.
+// The pump's state is written back to the file on Close.
+func Load(file string) (*Pump, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return &Pump{err: err}, err
+	}
+	defer func() { _ = f.Close() }()
+	var config Config
+	err = json.NewDecoder(f).Decode(&config)
+	if err != nil {
+		return &Pump{err: err}, err
+	}
+	sim := New(config)
+	sim.saveFile = file
+	return sim, sim.err
+}
+
+// Save writes the simulated pump's state to a JSON file.
+func (sim *Pump) Save(file string) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	e := json.NewEncoder(f)
+	e.SetIndent("", "  ")
+	err = e.Encode(sim.Config())
+	cerr := f.Close()
+	if err != nil {
+		return err
+	}
+	return cerr
+}
+
+// Config returns the current state of the simulated pump.
+func (sim *Pump) Config() Config {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	c := sim.config
+	c.HistoryPages = sim.historyPages()
+	return c
+}
+
+func deviceAddress(id string) ([]byte, error) {
+	if len(id) != 6 {
+		return nil, fmt.Errorf("device ID %q must be 6 digits", id)
+	}
+	h, err := strconv.ParseUint(id, 16, 24)
+	if err != nil {
+		return nil, fmt.Errorf("device ID %q: %v", id, err)
+	}
+	return []byte{byte(h >> 16), byte(h >> 8), byte(h >> 0)}, nil
+}
+
+func modelFamily(model string) (int, error) {
+	n, err := strconv.Atoi(model)
+	if err != nil {
+		return 0, err
+	}
+	if 500 < n && n < 600 {
+		return n - 500, nil
+	}
+	if 700 < n && n < 800 {
+		return n - 700, nil
+	}
+	return 0, fmt.Errorf("unsupported pump model %d", n)
+}
+
+// Init initializes the radio device.
+func (sim *Pump) Init(freq uint32) {
+	sim.SetFrequency(freq)
+}
+
+// Reset resets the radio device.
+func (sim *Pump) Reset() {
+	sim.mu.Lock()
+	sim.pending = nil
+	sim.mu.Unlock()
+}
+
+// Close closes the radio device, saving the pump's state if it was loaded from a file.
+func (sim *Pump) Close() {
+	if sim.saveFile == "" || sim.addr == nil {
+		return
+	}
+	err := sim.Save(sim.saveFile)
+	if err != nil {
+		log.Printf("%s: %v", sim.saveFile, err)
+	}
+}
+
+// Frequency returns the radio's current frequency, in Hertz.
+func (sim *Pump) Frequency() uint32 {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	return sim.freq
+}
+
+// SetFrequency sets the radio to the given frequency, in Hertz.
+func (sim *Pump) SetFrequency(freq uint32) {
+	sim.mu.Lock()
+	sim.freq = freq
+	sim.mu.Unlock()
+}
+
+// Send transmits the given packet.
+func (sim *Pump) Send(data []byte) {
+	sim.mu.Lock()
+	sim.pending = sim.receive(data)
+	sim.mu.Unlock()
+}
+
+// Receive listens with the given timeout for an incoming packet.
+// It returns the packet and the associated RSSI.
+func (sim *Pump) Receive(timeout time.Duration) ([]byte, int) {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	p := sim.pending
+	sim.pending = nil
+	if p == nil {
+		return nil, 0
+	}
+	return p, sim.rssi()
+}
+
+// SendAndReceive transmits the given packet,
+// then listens with the given timeout for an incoming packet.
+// It returns the packet and the associated RSSI.
+func (sim *Pump) SendAndReceive(data []byte, timeout time.Duration) ([]byte, int) {
+	sim.Send(data)
+	return sim.Receive(timeout)
+}
+
+// State returns the radio's current state as a string.
+func (sim *Pump) State() string {
+	return "idle"
+}
+
+// Error returns the error state of the radio device.
+func (sim *Pump) Error() error {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	return sim.err
+}
+
+// SetError sets the error state of the radio device.
+func (sim *Pump) SetError(err error) {
+	sim.mu.Lock()
+	sim.err = err
+	sim.mu.Unlock()
+}
+
+// Name returns the radio's name.
+func (sim *Pump) Name() string {
+	return "simulator"
+}
+
+// Device returns the pathname of the radio's device.
+func (sim *Pump) Device() string {
+	return "model " + sim.config.Model + " pump " + sim.config.ID
+}
+
+// inRange reports whether the pump can hear the radio's current frequency.
+func (sim *Pump) inRange() bool {
+	d := int64(sim.freq) - int64(sim.config.Frequency)
+	return -bandwidth <= d && d <= bandwidth
+}
+
+// rssi returns a signal strength that falls off with frequency error.
+func (sim *Pump) rssi() int {
+	d := int64(sim.freq) - int64(sim.config.Frequency)
+	if d < 0 {
+		d = -d
+	}
+	return sim.config.RSSI - int(d/10000)
+}
+
+// receive decodes a packet sent to the pump and returns the encoded reply, if any.
+func (sim *Pump) receive(p []byte) []byte {
+	if sim.addr == nil || !sim.inRange() {
+		return nil
+	}
+	data, err := packet.Decode(p)
+	if err != nil {
+		// The pump ignores packets it cannot decode.
+		return nil
+	}
+	if len(data) < 6 || data[0] != packet.Pump || !sameAddress(data[1:4], sim.addr) {
+		return nil
+	}
+	reply := sim.handle(data)
+	if reply == nil {
+		return nil
+	}
+	return packet.Encode(reply)
+}
+
+func sameAddress(a []byte, b []byte) bool {
+	return a[0] == b[0] && a[1] == b[1] && a[2] == b[2]
+}
@@ -2,6 +2,9 @@ package medtronic
 
 // Suspend suspends or resumes the pump.
 func (pump *Pump) Suspend(yes bool) {
+	if !pump.allow(GuardRequest{Command: suspend, Suspend: yes}) {
+		return
+	}
 	if yes {
 		pump.Execute(suspend, 1)
 	} else {
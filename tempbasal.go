@@ -70,6 +70,11 @@ func (pump *Pump) SetAbsoluteTempBasal(duration time.Duration, rate Insulin) {
 		pump.SetError(err)
 		return
 	}
+	actual := Insulin(r) * milliUnitsPerStroke(23)
+	if !pump.allow(GuardRequest{Command: setAbsoluteTempBasal, Amount: actual, Duration: duration}) {
+		return
+	}
+	pump.logRounding("temporary basal rate", rate, actual)
 	args := append(marshalUint16(r), d)
 	pump.Execute(setAbsoluteTempBasal, args...)
 }
@@ -81,6 +86,9 @@ func (pump *Pump) SetPercentTempBasal(duration time.Duration, percent int) {
 		pump.SetError(fmt.Errorf("percent temporary basal rate (%d) is not between 0 and 100", percent))
 		return
 	}
+	if !pump.allow(GuardRequest{Command: setPercentTempBasal, Percent: percent, Duration: duration}) {
+		return
+	}
 	pump.Execute(setPercentTempBasal, byte(percent), d)
 }
 
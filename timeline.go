@@ -0,0 +1,287 @@
+package medtronic
+
+import (
+	"time"
+)
+
+// DeliveryType distinguishes the kinds of insulin delivery segments.
+type DeliveryType int
+
+//go:generate stringer -type DeliveryType
+
+// Delivery types.
+const (
+	ScheduledDelivery DeliveryType = iota
+	TempBasalDelivery
+	SuspendedDelivery
+	BolusDelivery
+)
+
+// DeliverySegment represents insulin delivered at a constant rate
+// from the start time up to (but not including) the end time.
+// A normal bolus has equal start and end times and a zero rate.
+type DeliverySegment struct {
+	Type   DeliveryType
+	Start  time.Time
+	End    time.Time
+	Rate   Insulin // milliUnits per hour
+	Amount Insulin
+}
+
+// DeliveryTimeline represents the insulin delivered over a period of time.
+// The basal segments are contiguous and do not overlap;
+// the bolus segments are in addition to them.
+// Both are in chronological order.
+type DeliveryTimeline struct {
+	Basal   []DeliverySegment
+	Boluses []DeliverySegment
+}
+
+// BasalPatterns holds the standard basal rate schedule and patterns A and B,
+// indexed by pattern number (as in SettingsInfo.SelectedPattern).
+type BasalPatterns [3]BasalRateSchedule
+
+func (p BasalPatterns) schedule(pattern int) BasalRateSchedule {
+	if pattern < 0 || pattern >= len(p) {
+		pattern = 0
+	}
+	return p[pattern]
+}
+
+// nextBasalChange returns the time of the first schedule boundary after t.
+func nextBasalChange(sched BasalRateSchedule, t time.Time) time.Time {
+	d := time.Duration(SinceMidnight(t))
+	midnight := t.Add(-d)
+	for _, v := range sched {
+		if time.Duration(v.Start) > d {
+			return midnight.Add(time.Duration(v.Start))
+		}
+	}
+	return midnight.AddDate(0, 0, 1)
+}
+
+func earlier(t1 time.Time, t2 time.Time) time.Time {
+	if t2.Before(t1) {
+		return t2
+	}
+	return t1
+}
+
+// deliveryState tracks the basal delivery in effect while history records are replayed.
+type deliveryState struct {
+	timeline  DeliveryTimeline
+	patterns  BasalPatterns
+	pattern   int
+	cur       time.Time // start of the delivery not yet added to the timeline
+	temp      *TempBasalRecord
+	tempEnd   time.Time
+	suspended bool
+	rewound   bool      // suspended by a rewind rather than a SuspendPump record
+	primed    time.Time // time of the last prime after a rewind
+}
+
+// fill adds basal segments from the current time up to t.
+func (s *deliveryState) fill(t time.Time) {
+	for s.cur.Before(t) {
+		sched := s.patterns.schedule(s.pattern)
+		scheduled := float64(sched.BasalRateAt(s.cur).Rate)
+		seg := DeliverySegment{Start: s.cur}
+		end := t
+		switch {
+		case s.suspended:
+			seg.Type = SuspendedDelivery
+		case s.temp != nil && s.cur.Before(s.tempEnd):
+			seg.Type = TempBasalDelivery
+			end = earlier(end, s.tempEnd)
+			if s.temp.Type == Percent {
+				end = earlier(end, nextBasalChange(sched, s.cur))
+			}
+			seg.Rate = Insulin(s.temp.rate(scheduled))
+		default:
+			seg.Type = ScheduledDelivery
+			end = earlier(end, nextBasalChange(sched, s.cur))
+			seg.Rate = Insulin(scheduled)
+		}
+		seg.End = end
+		s.addBasal(seg)
+		s.cur = end
+	}
+}
+
+// addBasal adds a basal segment, merging it with the previous one
+// if they are of the same type and rate.
+func (s *deliveryState) addBasal(seg DeliverySegment) {
+	basal := s.timeline.Basal
+	n := len(basal)
+	if n != 0 {
+		last := &basal[n-1]
+		if last.Type == seg.Type && last.Rate == seg.Rate && last.End.Equal(seg.Start) {
+			last.End = seg.End
+			last.Amount = basalAmount(last.Rate, last.End.Sub(last.Start))
+			return
+		}
+	}
+	seg.Amount = basalAmount(seg.Rate, seg.End.Sub(seg.Start))
+	s.timeline.Basal = append(basal, seg)
+}
+
+func basalAmount(rate Insulin, d time.Duration) Insulin {
+	return Insulin(int64(rate) * int64(d) / int64(time.Hour))
+}
+
+func (s *deliveryState) suspend(t time.Time, rewound bool) {
+	s.fill(t)
+	if !s.suspended {
+		s.suspended = true
+		s.rewound = rewound
+	} else if !rewound {
+		s.rewound = false
+	}
+	s.primed = time.Time{}
+}
+
+func (s *deliveryState) resume(t time.Time) {
+	s.fill(t)
+	s.suspended = false
+	s.rewound = false
+	s.primed = time.Time{}
+}
+
+// resumeAfterPrime ends a suspension caused by a rewind at the last prime,
+// for pumps that do not record when delivery resumes.
+func (s *deliveryState) resumeAfterPrime() {
+	if s.rewound && !s.primed.IsZero() {
+		s.resume(s.primed)
+	}
+}
+
+func (s *deliveryState) addBolus(t time.Time, b BolusRecord) {
+	seg := DeliverySegment{Type: BolusDelivery, Start: t, End: t, Amount: b.Amount}
+	if b.Duration != 0 && b.Programmed != 0 {
+		// A square wave bolus is delivered at the programmed rate until it finishes or is canceled.
+		d := time.Duration(b.Duration)
+		seg.Rate = Insulin(int64(b.Programmed) * int64(time.Hour) / int64(d))
+		seg.End = t.Add(time.Duration(int64(d) * int64(b.Amount) / int64(b.Programmed)))
+	}
+	s.timeline.Boluses = append(s.timeline.Boluses, seg)
+}
+
+// ComputeDeliveryTimeline reconstructs the insulin delivered from history records,
+// which must be in chronological order, up to time t.
+// The pattern argument is the basal pattern in effect at the time of the first record;
+// ChangeBasalPattern records select a different one.
+// A temp basal or suspension in progress before the first record is not taken into account.
+// A rewind suspends delivery until the pump is resumed or, if that is not recorded,
+// until the basal schedule restarts or the last prime.
+// Delivery is considered to continue without interruption across a clock change,
+// and a temp basal in progress keeps its remaining duration.
+// Segment times are those of the pump's clock at the time of the last record:
+// records before a clock change are shifted by the amount it was changed, as by a TimeCorrector,
+// so segments do not overlap when the clock is set back.
+func ComputeDeliveryTimeline(records History, patterns BasalPatterns, pattern int, t time.Time) DeliveryTimeline {
+	if len(records) == 0 {
+		return DeliveryTimeline{}
+	}
+	records = clockCorrected(records)
+	s := deliveryState{patterns: patterns, pattern: pattern, cur: records[0].Time}
+	for i, r := range records {
+		if r.Time.After(t) {
+			continue
+		}
+		switch r.Type() {
+		case Bolus:
+			s.resumeAfterPrime()
+			s.addBolus(r.Time, r.Info.(BolusRecord))
+		case TempBasalRate:
+			if i+1 == len(records) || records[i+1].Type() != TempBasalDuration {
+				break
+			}
+			s.resumeAfterPrime()
+			s.fill(r.Time)
+			temp := r.Info.(TempBasalRecord)
+			s.temp = &temp
+			s.tempEnd = r.Time.Add(time.Duration(records[i+1].Info.(Duration)))
+		case SuspendPump:
+			s.suspend(r.Time, false)
+		case Rewind:
+			s.suspend(r.Time, true)
+		case Prime:
+			if s.rewound {
+				s.primed = r.Time
+			}
+		case ResumePump:
+			s.resume(r.Time)
+		case BasalProfileStart:
+			if s.rewound && !s.primed.IsZero() {
+				s.resume(r.Time)
+			}
+		case ChangeBasalPattern:
+			s.fill(r.Time)
+			s.pattern = r.Info.(int)
+		}
+	}
+	s.resumeAfterPrime()
+	s.fill(t)
+	return s.timeline
+}
+
+// clockCorrected returns a copy of the records, which must be in chronological order,
+// with their times corrected by a TimeCorrector relative to the last record
+// and kept in the location they were decoded in.
+func clockCorrected(records History) History {
+	corrected := make(History, len(records))
+	copy(corrected, records)
+	ReverseHistory(corrected)
+	c := TimeCorrector{}
+	c.Correct(corrected)
+	ReverseHistory(corrected)
+	for i, r := range records {
+		if r.Time.IsZero() {
+			continue
+		}
+		u := corrected[i].Time
+		corrected[i].Time = time.Date(u.Year(), u.Month(), u.Day(), u.Hour(), u.Minute(), u.Second(), u.Nanosecond(), r.Time.Location())
+	}
+	return corrected
+}
+
+// DeliveryTimeline returns the insulin delivered since the given time,
+// using the pump's history and basal patterns.
+// If the basal pattern was changed during that time, the pattern in effect
+// before the first change is assumed to be the standard one.
+func (pump *Pump) DeliveryTimeline(since time.Time) DeliveryTimeline {
+	var patterns BasalPatterns
+	patterns[0] = pump.BasalRates()
+	if pump.Error() != nil {
+		return DeliveryTimeline{}
+	}
+	patterns[1] = pump.BasalPatternA()
+	if pump.Error() != nil {
+		return DeliveryTimeline{}
+	}
+	patterns[2] = pump.BasalPatternB()
+	if pump.Error() != nil {
+		return DeliveryTimeline{}
+	}
+	settings := pump.Settings()
+	if pump.Error() != nil {
+		return DeliveryTimeline{}
+	}
+	t := pump.Clock()
+	if pump.Error() != nil {
+		return DeliveryTimeline{}
+	}
+	records := pump.History(since)
+	if pump.Error() != nil {
+		return DeliveryTimeline{}
+	}
+	ReverseHistory(records)
+	pattern := settings.SelectedPattern
+	for _, r := range records {
+		if r.Type() == ChangeBasalPattern {
+			pattern = 0
+			break
+		}
+	}
+	return ComputeDeliveryTimeline(records, patterns, pattern, t)
+}
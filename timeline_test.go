@@ -0,0 +1,237 @@
+package medtronic
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+type testSegment struct {
+	typ   DeliveryType
+	start string
+	end   string
+	rate  Insulin
+}
+
+func (s testSegment) segment() DeliverySegment {
+	start := parseTime("2018-06-20T" + s.start)
+	end := parseTime("2018-06-20T" + s.end)
+	return DeliverySegment{
+		Type:   s.typ,
+		Start:  start,
+		End:    end,
+		Rate:   s.rate,
+		Amount: basalAmount(s.rate, end.Sub(start)),
+	}
+}
+
+func TestComputeDeliveryTimeline(t *testing.T) {
+	patterns := BasalPatterns{
+		{{Start: 0, Rate: 1000}, {Start: parseTD("11:00"), Rate: 1500}},
+		{{Start: 0, Rate: 500}},
+		{{Start: 0, Rate: 2000}},
+	}
+	cases := []struct {
+		records History
+		pattern int
+		basal   []testSegment
+	}{
+		{
+			History{testRecord(BGCapture, "10:00", nil)},
+			0,
+			[]testSegment{
+				{ScheduledDelivery, "10:00", "11:00", 1000},
+				{ScheduledDelivery, "11:00", "12:00", 1500},
+			},
+		},
+		{
+			History{testRecord(BGCapture, "10:00", nil)},
+			1,
+			[]testSegment{
+				{ScheduledDelivery, "10:00", "12:00", 500},
+			},
+		},
+		{
+			tempBasalRecords("10:30", 0, time.Hour),
+			0,
+			[]testSegment{
+				{TempBasalDelivery, "10:30", "11:30", 0},
+				{ScheduledDelivery, "11:30", "12:00", 1500},
+			},
+		},
+		{
+			History{
+				testRecord(TempBasalRate, "10:30", TempBasalRecord{Type: Percent, Value: 200}),
+				testRecord(TempBasalDuration, "10:30", Duration(time.Hour)),
+			},
+			0,
+			[]testSegment{
+				{TempBasalDelivery, "10:30", "11:00", 2000},
+				{TempBasalDelivery, "11:00", "11:30", 3000},
+				{ScheduledDelivery, "11:30", "12:00", 1500},
+			},
+		},
+		{
+			// Canceled temp basal.
+			concat(tempBasalRecords("10:00", 2000, 2*time.Hour), tempBasalRecords("10:45", 0, 0)),
+			0,
+			[]testSegment{
+				{TempBasalDelivery, "10:00", "10:45", 2000},
+				{ScheduledDelivery, "10:45", "11:00", 1000},
+				{ScheduledDelivery, "11:00", "12:00", 1500},
+			},
+		},
+		{
+			// Suspended during a temp basal.
+			concat(
+				tempBasalRecords("10:00", 2000, time.Hour),
+				History{
+					testRecord(SuspendPump, "10:30", nil),
+					testRecord(ResumePump, "10:45", nil),
+				},
+			),
+			0,
+			[]testSegment{
+				{TempBasalDelivery, "10:00", "10:30", 2000},
+				{SuspendedDelivery, "10:30", "10:45", 0},
+				{TempBasalDelivery, "10:45", "11:00", 2000},
+				{ScheduledDelivery, "11:00", "12:00", 1500},
+			},
+		},
+		{
+			// Rewind and prime without a resume record.
+			History{
+				testRecord(Rewind, "10:00", nil),
+				testRecord(Prime, "10:10", nil),
+				testRecord(Prime, "10:20", nil),
+				testRecord(BGCapture, "10:30", nil),
+			},
+			0,
+			[]testSegment{
+				{SuspendedDelivery, "10:00", "10:20", 0},
+				{ScheduledDelivery, "10:20", "11:00", 1000},
+				{ScheduledDelivery, "11:00", "12:00", 1500},
+			},
+		},
+		{
+			History{
+				testRecord(Rewind, "10:00", nil),
+				testRecord(Prime, "10:10", nil),
+				testRecord(BasalProfileStart, "10:15", nil),
+			},
+			0,
+			[]testSegment{
+				{SuspendedDelivery, "10:00", "10:15", 0},
+				{ScheduledDelivery, "10:15", "11:00", 1000},
+				{ScheduledDelivery, "11:00", "12:00", 1500},
+			},
+		},
+		{
+			History{
+				testRecord(BGCapture, "10:00", nil),
+				testRecord(ChangeBasalPattern, "10:30", 2),
+				testRecord(ChangeBasalPattern, "11:30", 0),
+			},
+			0,
+			[]testSegment{
+				{ScheduledDelivery, "10:00", "10:30", 1000},
+				{ScheduledDelivery, "10:30", "11:30", 2000},
+				{ScheduledDelivery, "11:30", "12:00", 1500},
+			},
+		},
+		{
+			// Clock set back by 30 minutes during a temp basal.
+			concat(
+				tempBasalRecords("10:00", 0, time.Hour),
+				History{
+					testRecord(ChangeTime, "10:30", nil),
+					testRecord(NewTime, "10:00", nil),
+				},
+			),
+			0,
+			[]testSegment{
+				{TempBasalDelivery, "09:30", "10:30", 0},
+				{ScheduledDelivery, "10:30", "11:00", 1000},
+				{ScheduledDelivery, "11:00", "12:00", 1500},
+			},
+		},
+	}
+	now := parseTime("2018-06-20T12:00")
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("case%d", i+1), func(t *testing.T) {
+			timeline := ComputeDeliveryTimeline(c.records, patterns, c.pattern, now)
+			if len(timeline.Basal) != len(c.basal) {
+				t.Errorf("ComputeDeliveryTimeline == %+v, want %+v", timeline.Basal, c.basal)
+				return
+			}
+			for j, s := range c.basal {
+				want := s.segment()
+				if !segmentsEqual(timeline.Basal[j], want) {
+					t.Errorf("segment %d == %+v, want %+v", j, timeline.Basal[j], want)
+				}
+			}
+		})
+	}
+}
+
+func segmentsEqual(s1 DeliverySegment, s2 DeliverySegment) bool {
+	return s1.Type == s2.Type && s1.Start.Equal(s2.Start) && s1.End.Equal(s2.End) &&
+		s1.Rate == s2.Rate && s1.Amount == s2.Amount
+}
+
+func TestDeliveryTimelineBoluses(t *testing.T) {
+	records := History{
+		testRecord(Bolus, "10:00", BolusRecord{Programmed: 3000, Amount: 3000}),
+		// Square wave bolus canceled after 30 minutes.
+		testRecord(Bolus, "11:00", BolusRecord{Programmed: 1200, Amount: 600, Duration: Duration(time.Hour)}),
+	}
+	want := []testSegment{
+		{BolusDelivery, "10:00", "10:00", 0},
+		{BolusDelivery, "11:00", "11:30", 1200},
+	}
+	amounts := []Insulin{3000, 600}
+	timeline := ComputeDeliveryTimeline(records, BasalPatterns{{{Start: 0, Rate: 1000}}}, 0, parseTime("2018-06-20T12:00"))
+	if len(timeline.Boluses) != len(want) {
+		t.Fatalf("ComputeDeliveryTimeline == %+v, want %+v", timeline.Boluses, want)
+	}
+	for i, s := range want {
+		b := s.segment()
+		b.Amount = amounts[i]
+		if !segmentsEqual(timeline.Boluses[i], b) {
+			t.Errorf("bolus %d == %+v, want %+v", i, timeline.Boluses[i], b)
+		}
+	}
+}
+
+func TestDeliveryTimelineClockChange(t *testing.T) {
+	// Clock set back by 45 minutes after a temp basal, followed by a bolus.
+	records := concat(
+		tempBasalRecords("10:00", 0, time.Hour),
+		History{
+			testRecord(ChangeTime, "10:45", nil),
+			testRecord(NewTime, "10:00", nil),
+			testRecord(Bolus, "10:10", BolusRecord{Programmed: 2000, Amount: 2000}),
+		},
+	)
+	timeline := ComputeDeliveryTimeline(records, BasalPatterns{{{Start: 0, Rate: 1000}}}, 0, parseTime("2018-06-20T10:20"))
+	basal := []testSegment{
+		{TempBasalDelivery, "09:15", "10:15", 0},
+		{ScheduledDelivery, "10:15", "10:20", 1000},
+	}
+	if len(timeline.Basal) != len(basal) {
+		t.Fatalf("ComputeDeliveryTimeline == %+v, want %+v", timeline.Basal, basal)
+	}
+	for i, s := range basal {
+		if !segmentsEqual(timeline.Basal[i], s.segment()) {
+			t.Errorf("segment %d == %+v, want %+v", i, timeline.Basal[i], s.segment())
+		}
+	}
+	bolus := testSegment{BolusDelivery, "10:10", "10:10", 0}.segment()
+	bolus.Amount = 2000
+	if len(timeline.Boluses) != 1 || !segmentsEqual(timeline.Boluses[0], bolus) {
+		t.Errorf("ComputeDeliveryTimeline == %+v, want %+v", timeline.Boluses, bolus)
+	}
+	if !records[0].Time.Equal(parseTime("2018-06-20T10:00")) {
+		t.Errorf("ComputeDeliveryTimeline modified record times")
+	}
+}
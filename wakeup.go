@@ -1,7 +1,6 @@
 package medtronic
 
 import (
-	"log"
 	"time"
 )
 
@@ -26,7 +25,7 @@ func (pump *Pump) Wakeup() {
 		return
 	}
 	pump.SetError(nil)
-	log.Printf("waking pump")
+	pump.Logger().Info("waking pump")
 	n := pump.Retries()
 	defer pump.SetRetries(n)
 	t := pump.Timeout()